@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"github.com/c-bata/go-prompt"
+	"github.com/marco-lancini/goscan/core/enum"
 	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
 	"io/ioutil"
@@ -41,9 +42,13 @@ var commands = []prompt.Suggest{
 	{Text: "sweep", Description: "Perform a Ping Sweep to discover alive hosts."},
 	{Text: "portscan", Description: "Perform a port scan."},
 	{Text: "enumerate", Description: "Perform enumeration of detected services."},
+	{Text: "spray", Description: "Try the credentials found against the other services in scope."},
 	{Text: "special", Description: "Special scans (EyeWitness, Domain Info, DNS)."},
 	{Text: "show", Description: "Show results (hosts/ports/etc/)."},
-	{Text: "set", Description: "Set different constants (output folder, nmap switches, wordlists)."},
+	{Text: "jobs", Description: "Show running and queued jobs."},
+	{Text: "audit", Description: "Verify and export the audit log."},
+	{Text: "creds", Description: "Export the credentials found."},
+	{Text: "set", Description: "Set different constants (output folder, nmap switches, wordlists, scanning windows)."},
 	{Text: "help", Description: "Show help"},
 	{Text: "exit", Description: "Exit this program"},
 }
@@ -64,9 +69,20 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 				{Text: "targets", Description: "Show targets."},
 				{Text: "hosts", Description: "Show live hosts."},
 				{Text: "ports", Description: "Show detailed ports information."},
+				{Text: "windows", Description: "Show the scanning windows of the workspace."},
+				{Text: "recipes", Description: "Show the enumeration recipes."},
+				{Text: "details", Description: "Show the details extracted by the enumeration."},
+				{Text: "findings", Description: "Show the findings flagged by the enumeration."},
+				{Text: "web", Description: "Show the fingerprint of the web services."},
+				{Text: "shares", Description: "Show the SMB shares enumerated."},
+				{Text: "creds", Description: "Show the credentials found."},
+				{Text: "inventory", Description: "Show the inventory of a host collected via SNMP."},
 			}
 			return prompt.FilterHasPrefix(subcommands, args[1], true)
 		}
+		if len(args) == 3 && (args[1] == "details" || args[1] == "findings" || args[1] == "web" || args[1] == "shares" || args[1] == "creds" || args[1] == "inventory") {
+			return prompt.FilterContains(getHostSuggestions(), args[2], true)
+		}
 
 	case "set":
 		if len(args) == 2 {
@@ -75,15 +91,52 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 				{Text: "output_folder", Description: "Set the output folder."},
 				{Text: "nmap_switches", Description: "Modify the default nmap switches."},
 				{Text: "wordlists", Description: "Modify the default wordlists."},
+				{Text: "window", Description: "Define the scanning windows (rules of engagement)."},
+				{Text: "recipes_folder", Description: "Set the folder of the user-defined enumeration recipes."},
+				{Text: "passphrase", Description: "Unlock the credential vault of the workspace."},
+				{Text: "snmp_v3", Description: "Set the SNMPv3 credentials."},
+				{Text: "engine", Description: "Select the port scan engine."},
+				{Text: "native_scan", Description: "Modify the options of the native scanner."},
 			}
 			return prompt.FilterHasPrefix(subcommands, args[1], true)
 		}
 		if len(args) == 3 {
 			switch args[1] {
+			case "window":
+				subcommands := []prompt.Suggest{
+					{Text: "ALLOW", Description: "Allow scans only within this window"},
+					{Text: "BLACKOUT", Description: "Forbid scans during this period"},
+					{Text: "CLEAR", Description: "Remove all scanning windows"},
+				}
+				return prompt.FilterHasPrefix(subcommands, args[2], true)
+			case "snmp_v3":
+				subcommands := []prompt.Suggest{
+					{Text: "CLEAR", Description: "Remove the SNMPv3 credentials"},
+				}
+				return prompt.FilterHasPrefix(subcommands, args[2], true)
+			case "engine":
+				subcommands := []prompt.Suggest{
+					{Text: "NMAP", Description: "Run port scans with nmap (default)"},
+					{Text: "NATIVE", Description: "Built-in TCP connect scanner"},
+				}
+				return prompt.FilterHasPrefix(subcommands, args[2], true)
+			case "native_scan":
+				subcommands := []prompt.Suggest{
+					{Text: "PORTS", Description: "Ports to scan instead of the profile ones (e.g. 22,80,8000-8100 or top-100, at most top-146)"},
+					{Text: "TIMEOUT", Description: "Connection timeout (ms)"},
+					{Text: "RETRIES", Description: "Retries of unanswered probes"},
+					{Text: "RATE", Description: "Maximum probes per second"},
+					{Text: "WORKERS", Description: "Concurrent connections"},
+					{Text: "BANNERS", Description: "Identify the services of the open ports (ON/OFF)"},
+					{Text: "SWEEP_PORTS", Description: "TCP ports probed by the native ping sweep (e.g. 22,80,443)"},
+				}
+				return prompt.FilterHasPrefix(subcommands, args[2], true)
 			case "config_file":
 				return fileCompleter(d)
 			case "output_folder":
 				return fileCompleter(d)
+			case "recipes_folder":
+				return fileCompleter(d)
 			case "nmap_switches":
 				subcommands := []prompt.Suggest{
 					{Text: "SWEEP", Description: "Switches for ping sweep"},
@@ -91,8 +144,11 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 					{Text: "TCP_STANDARD", Description: "Switches for TCP STANDARD scan"},
 					{Text: "TCP_VULN", Description: "Switches for TCP VULN scan"},
 					{Text: "TCP_PROD", Description: "Switches for TCP PROD scan"},
+					{Text: "TCP_DISCOVERY", Description: "Switches for the open ports discovery of the TCP PIPELINE scan"},
+					{Text: "TCP_SERVICES", Description: "Switches for the version detection of the TCP PIPELINE scan"},
 					{Text: "UDP_STANDARD", Description: "Switches for UDP STANDARD scan"},
 					{Text: "UDP_PROD", Description: "Switches for UDP PROD scan"},
+					{Text: "ICS", Description: "Switches for ICS scan"},
 				}
 				return prompt.FilterHasPrefix(subcommands, args[2], true)
 			case "wordlists":
@@ -106,10 +162,32 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 					{Text: "HYDRA_SSH_PASSWORD", Description: "Wordlist for SSH password bruteforce"},
 					{Text: "HYDRA_FTP_USER", Description: "Wordlist for FTP user bruteforce"},
 					{Text: "HYDRA_FTP_PASSWORD", Description: "Wordlist for FTP password bruteforce"},
+					{Text: "KERBEROS_USER", Description: "Wordlist for Kerberos user enumeration"},
 				}
 				return prompt.FilterHasPrefix(subcommands, args[2], true)
 			}
 		}
+		if len(args) == 4 && args[1] == "window" {
+			subcommands := []prompt.Suggest{
+				{Text: "22:00", Description: "Daily start time (UTC)"},
+				{Text: "2006-01-02T15:04", Description: "Absolute start time (UTC)"},
+			}
+			return prompt.FilterHasPrefix(subcommands, args[3], true)
+		}
+		if len(args) == 5 && args[1] == "window" {
+			subcommands := []prompt.Suggest{
+				{Text: "06:00", Description: "Daily end time (UTC)"},
+				{Text: "2006-01-02T15:04", Description: "Absolute end time (UTC)"},
+			}
+			return prompt.FilterHasPrefix(subcommands, args[4], true)
+		}
+		if len(args) == 6 && args[1] == "window" {
+			subcommands := []prompt.Suggest{
+				{Text: "PAUSE", Description: "Pause running jobs when the window closes"},
+				{Text: "CANCEL", Description: "Cancel running jobs when the window closes"},
+			}
+			return prompt.FilterHasPrefix(subcommands, args[5], true)
+		}
 		if len(args) == 4 {
 			switch args[1] {
 			case "nmap_switches":
@@ -139,6 +217,16 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 						{Text: utils.Const_NMAP_TCP_VULN, Description: "Default switches"},
 					}
 					return prompt.FilterHasPrefix(subcommands, args[3], true)
+				case "TCP_DISCOVERY":
+					subcommands := []prompt.Suggest{
+						{Text: utils.Const_NMAP_TCP_DISCOVERY, Description: "Default switches"},
+					}
+					return prompt.FilterHasPrefix(subcommands, args[3], true)
+				case "TCP_SERVICES":
+					subcommands := []prompt.Suggest{
+						{Text: utils.Const_NMAP_TCP_SERVICES, Description: "Default switches"},
+					}
+					return prompt.FilterHasPrefix(subcommands, args[3], true)
 				case "UDP_STANDARD":
 					subcommands := []prompt.Suggest{
 						{Text: utils.Const_NMAP_UDP_STANDARD, Description: "Default switches"},
@@ -149,6 +237,11 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 						{Text: utils.Const_NMAP_UDP_PROD, Description: "Default switches"},
 					}
 					return prompt.FilterHasPrefix(subcommands, args[3], true)
+				case "ICS":
+					subcommands := []prompt.Suggest{
+						{Text: utils.Const_NMAP_ICS, Description: "Default switches"},
+					}
+					return prompt.FilterHasPrefix(subcommands, args[3], true)
 				}
 
 			case "wordlists":
@@ -198,10 +291,38 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 						{Text: utils.WORDLIST_HYDRA_FTP_PWD, Description: "Default wordlist"},
 					}
 					return prompt.FilterHasPrefix(subcommands, args[3], true)
+				case "KERBEROS_USER":
+					subcommands := []prompt.Suggest{
+						{Text: utils.WORDLIST_KERBEROS_USER, Description: "Default wordlist"},
+					}
+					return prompt.FilterHasPrefix(subcommands, args[3], true)
 				}
 			}
 		}
 
+	case "audit":
+		if len(args) == 2 {
+			subcommands := []prompt.Suggest{
+				{Text: "verify", Description: "Verify the hash chain of the audit log."},
+				{Text: "export", Description: "Verify and export the audit log as JSON."},
+			}
+			return prompt.FilterHasPrefix(subcommands, args[1], true)
+		}
+		if len(args) == 3 && args[1] == "export" {
+			return fileCompleter(d)
+		}
+
+	case "creds":
+		if len(args) == 2 {
+			subcommands := []prompt.Suggest{
+				{Text: "export", Description: "Export the credentials as CSV (requires the passphrase)."},
+			}
+			return prompt.FilterHasPrefix(subcommands, args[1], true)
+		}
+		if len(args) == 3 && args[1] == "export" {
+			return fileCompleter(d)
+		}
+
 	// -----------------------------------------------------------------------------------
 	// LOAD TARGETS
 	// -----------------------------------------------------------------------------------
@@ -248,6 +369,7 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 		if len(args) == 2 {
 			subcommands := []prompt.Suggest{
 				{Text: "PING", Description: "Perform a Ping Sweep."},
+				{Text: "NATIVE", Description: "Built-in ping sweep (ICMP echo, TCP connect, ARP)."},
 			}
 			return prompt.FilterHasPrefix(subcommands, args[1], true)
 		}
@@ -264,9 +386,12 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 				{Text: "TCP-FULL", Description: "Perform FULL TCP scan"},
 				{Text: "TCP-STANDARD", Description: "Perform TCP scan (top 200)"},
 				{Text: "TCP-PROD", Description: "Perform PROD TCP scan (T3, no scripts)"},
+				{Text: "TCP-PIPELINE", Description: "Find the open ports, then -sV -sC on them only"},
 				{Text: "TCP-VULN-SCAN", Description: "Perform TCP VULN scan (vulscan.nse)"},
 				{Text: "UDP-STANDARD", Description: "Perform UDP scan (common ports)"},
 				{Text: "UDP-PROD", Description: "Perform PROD UDP scan (T3, no scripts)"},
+				{Text: "ICS", Description: "Discover industrial devices (T2, connect only, no version detection)"},
+				{Text: "BANNERS", Description: "Identify the services of the open TCP ports (banner grabbing)"},
 			}
 			return prompt.FilterHasPrefix(subcommands, args[1], true)
 		}
@@ -279,18 +404,7 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 	// -----------------------------------------------------------------------------------
 	case "enumerate":
 		if len(args) == 2 {
-			subcommands := []prompt.Suggest{
-				{Text: "ALL", Description: "Automatically identify open services and enumerate them"},
-				{Text: "FINGER", Description: "Enumerate FINGER"},
-				{Text: "FTP", Description: "Enumerate FTP"},
-				{Text: "HTTP", Description: "Enumerate HTTP"},
-				{Text: "RDP", Description: "Enumerate RDP"},
-				{Text: "SMB", Description: "Enumerate SMB"},
-				{Text: "SMTP", Description: "Enumerate SMTP"},
-				{Text: "SNMP", Description: "Enumerate SNMP"},
-				{Text: "SQL", Description: "Enumerate SQL"},
-				{Text: "SSH", Description: "Enumerate SSH"},
-			}
+			subcommands := getRecipeSuggestions()
 			return prompt.FilterHasPrefix(subcommands, args[1], true)
 		}
 		if len(args) == 3 {
@@ -306,6 +420,18 @@ func argumentsCompleter(d prompt.Document, args []string) []prompt.Suggest {
 			return prompt.FilterContains(getEnumerationSuggestions(), args[3], true)
 		}
 
+	case "spray":
+		if len(args) == 2 {
+			subcommands := []prompt.Suggest{
+				{Text: "DRY", Description: "Only show the attempts that would be performed"},
+				{Text: "RUN", Description: "One attempt per account and host (lockout thresholds respected)"},
+			}
+			return prompt.FilterHasPrefix(subcommands, args[1], true)
+		}
+		if len(args) == 3 {
+			return prompt.FilterContains(getEnumerationSuggestions(), args[2], true)
+		}
+
 	// -----------------------------------------------------------------------------------
 	// SPECIAL SCANS
 	// -----------------------------------------------------------------------------------
@@ -452,6 +578,38 @@ func getEnumerationSuggestions() []prompt.Suggest {
 	return s
 }
 
+func getHostSuggestions() []prompt.Suggest {
+	s := []prompt.Suggest{}
+	if !utils.IsDBAvailable() {
+		return s
+	}
+	for _, h := range model.GetAllHosts(utils.Config.DB) {
+		s = append(s, prompt.Suggest{
+			Text:        h.Address,
+			Description: fmt.Sprintf("Host: %s", h.Address),
+		})
+	}
+	return s
+}
+
+func getRecipeSuggestions() []prompt.Suggest {
+	recipes := enum.GetRecipes()
+	s := make([]prompt.Suggest, 1, len(recipes)+1)
+	s[0] = prompt.Suggest{
+		Text:        "ALL",
+		Description: "Automatically identify open services and enumerate them",
+	}
+
+	for _, kind := range enum.RecipeKinds(recipes) {
+		s = append(s, prompt.Suggest{
+			Text:        kind,
+			Description: recipes[kind].Description,
+		})
+	}
+
+	return s
+}
+
 func fileCompleter(d prompt.Document) []prompt.Suggest {
 	path := d.GetWordBeforeCursor()
 	if strings.HasPrefix(path, "./") {
@@ -2,10 +2,14 @@ package cli
 
 import (
 	"bufio"
+	"encoding/csv"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/marco-lancini/goscan/core/enum"
 	"github.com/marco-lancini/goscan/core/model"
@@ -18,6 +22,11 @@ func Executor(s string) {
 	// Parse cmd
 	cmd, args := utils.ParseCmd(s)
 
+	// Record the operator action, it becomes the origin of the jobs it starts
+	if cmd != "" {
+		utils.AuditAction(utils.RedactSecrets(strings.TrimSpace(s)))
+	}
+
 	// Execute commands
 	switch cmd {
 	case "load":
@@ -28,10 +37,18 @@ func Executor(s string) {
 		cmdPortscan(args)
 	case "enumerate":
 		cmdEnumerate(args)
+	case "spray":
+		cmdSpray(args)
 	case "special":
 		cmdSpecial(args)
 	case "show":
 		cmdShow(args)
+	case "jobs":
+		cmdJobs()
+	case "audit":
+		cmdAudit(args)
+	case "creds":
+		cmdCreds(args)
 	case "set":
 		cmdSet(args)
 	case "help":
@@ -61,15 +78,21 @@ func cmdHelp() {
 		[]string{"Load target", "Upload multiple targets from a text file or folder", "load target MULTI <path-to-file>"},
 
 		[]string{"Host Discovery", "Perform a Ping Sweep", "sweep <TYPE> <TARGET>"},
+		[]string{"Host Discovery", "Built-in ping sweep (ICMP echo, TCP connect probes, ARP), without nmap", "sweep NATIVE <TARGET>"},
 		[]string{"Load Host Discovery", "Add a single alive host via the CLI (must be a /32)", "load alive SINGLE <IP>"},
 		[]string{"Load Host Discovery", "Upload multiple alive hosts from a text file or folder", "load alive MULTI <path-to-file>"},
 
 		[]string{"Port Scan", "Perform a port scan", "portscan <TYPE> <TARGET>"},
+		[]string{"Port Scan", "Find the open ports first, then run version detection and default scripts on them only", "portscan TCP-PIPELINE <TARGET>"},
+		[]string{"Port Scan", "Discover industrial devices (slow, read-only probes)", "portscan ICS <TARGET>"},
+		[]string{"Port Scan", "Identify the services of the open TCP ports by their banners (no nmap needed)", "portscan BANNERS <TARGET>"},
 		[]string{"Load Port Scan", "Upload nmap port scan results from XML files or folder", "load portscan <path-to-file>"},
 
 		[]string{"Service Enumeration", "Dry Run (only show commands, without performing them", "enumerate <TYPE> DRY <TARGET>"},
 		[]string{"Service Enumeration", "Perform enumeration of detected services", "enumerate <TYPE> <POLITE/AGGRESSIVE> <TARGET>"},
 
+		[]string{"Credential Reuse", "Try the credentials found against SSH/FTP/SMB/MSSQL/MySQL services in scope", "spray <DRY/RUN> <TARGET>"},
+
 		[]string{"Special Scan - EyeWitness", "Take screenshots of websites, RDP services, and open VNC servers (KALI ONLY)", "special eyewitness"},
 
 		[]string{"Special Scan - Domain Info", "Extract Windows domain information from enumeration data", "special domain <users/hosts/servers>"},
@@ -81,11 +104,34 @@ func cmdHelp() {
 		[]string{"Show", "Show targets", "show targets"},
 		[]string{"Show", "Show live hosts", "show hosts"},
 		[]string{"Show", "Show detailed ports information", "show ports"},
+		[]string{"Show", "Show the scanning windows of the workspace", "show windows"},
+		[]string{"Show", "Show the enumeration recipes (built-in and user-defined)", "show recipes"},
+		[]string{"Show", "Show the details extracted by the enumeration (e.g., LDAP naming contexts)", "show details [<HOST>]"},
+		[]string{"Show", "Show the findings flagged by the enumeration (e.g., world-readable NFS exports)", "show findings [<HOST>]"},
+		[]string{"Show", "Show the fingerprint of the web services (title, server, headers, technologies, certificate), and the URLs and web findings of a host", "show web [<HOST>]"},
+		[]string{"Show", "Show the SMB shares enumerated (with the access obtained)", "show shares [<HOST>]"},
+		[]string{"Show", "Show the credentials found (secrets decrypted if the vault is unlocked)", "show creds [<HOST>]"},
+		[]string{"Show", "Show the inventory of a host collected via SNMP (processes, software, listening ports, interfaces)", "show inventory <HOST>"},
+		[]string{"Jobs", "Show running and queued jobs (and why they are waiting)", "jobs"},
+
+		[]string{"Audit", "Verify the hash chain of the audit log of the workspace", "audit verify"},
+		[]string{"Audit", "Verify and export the audit log (commands run, operator actions)", "audit export <PATH>"},
+
+		[]string{"Credentials", "Export the credentials found as CSV (plaintext, requires the passphrase)", "creds export <PATH>"},
 
 		[]string{"Utils", "Set configs from file", "set config_file <PATH>"},
 		[]string{"Utils", "Set output folder", "set output_folder <PATH>"},
-		[]string{"Utils", "Modify the default nmap switches", "set nmap_switches <SWEEP/TCP_FULL/TCP_STANDARD/TCP_VULN/UDP_STANDARD> <SWITCHES>"},
+		[]string{"Utils", "Modify the default nmap switches", "set nmap_switches <SWEEP/TCP_FULL/TCP_STANDARD/TCP_VULN/TCP_DISCOVERY/TCP_SERVICES/UDP_STANDARD/ICS> <SWITCHES>"},
 		[]string{"Utils", "Modify the default wordlists", "set wordlists <FINGER_USER/FTP_USER/...> <PATH>"},
+		[]string{"Utils", "Select the port scan engine (native: built-in TCP connect scanner, used anyway when nmap is missing)", "set engine <NMAP/NATIVE>"},
+		[]string{"Utils", "Modify the options of the native scanner (ports: e.g. 22,80,8000-8100 or top-100, timeout in ms, rate in probes/s)", "set native_scan <PORTS/TIMEOUT/RETRIES/RATE/WORKERS/BANNERS/SWEEP_PORTS> <VALUE>"},
+		[]string{"Utils", "Set the folder of the user-defined enumeration recipes (YAML)", "set recipes_folder <PATH>"},
+		[]string{"Utils", "Unlock the credential vault of the workspace (created on first use)", "set passphrase <PASSPHRASE>"},
+		[]string{"Utils", "Set the SNMPv3 credentials tried before the community strings (MD5/SHA/SHA256..., DES/AES/AES256...)", "set snmp_v3 <USER> [<AUTH_PROTOCOL> <AUTH_PASS> [<PRIV_PROTOCOL> <PRIV_PASS>]]"},
+		[]string{"Utils", "Remove the SNMPv3 credentials", "set snmp_v3 CLEAR"},
+		[]string{"Rules of Engagement", "Allow scans only within a time window (UTC, HH:MM or YYYY-MM-DDTHH:MM)", "set window ALLOW <START> <END> <PAUSE/CANCEL>"},
+		[]string{"Rules of Engagement", "Forbid scans during a blackout period (UTC, HH:MM or YYYY-MM-DDTHH:MM)", "set window BLACKOUT <START> <END> <PAUSE/CANCEL>"},
+		[]string{"Rules of Engagement", "Remove all the scanning windows", "set window CLEAR"},
 
 		[]string{"Utils", "Exit this program", "exit"},
 	}
@@ -286,6 +332,25 @@ func cmdEnumerate(args []string) {
 	enum.ScanEnumerate(kind, polite, target)
 }
 
+// ---------------------------------------------------------------------------------------
+// SPRAY
+// ---------------------------------------------------------------------------------------
+func cmdSpray(args []string) {
+	// Check arguments length to ensure all required options have been provided
+	if len(args) != 2 {
+		utils.Config.Log.LogError("Invalid command provided")
+		return
+	}
+	// Parse mode and target host
+	mode, args := utils.ParseNextArg(args)
+	target, _ := utils.ParseNextArg(args)
+	if mode != "DRY" && mode != "RUN" {
+		utils.Config.Log.LogError("Invalid mode provided (DRY/RUN)")
+		return
+	}
+	enum.ScanSpray(mode, target)
+}
+
 // ---------------------------------------------------------------------------------------
 // SPECIAL SCANS
 // ---------------------------------------------------------------------------------------
@@ -315,11 +380,11 @@ func cmdSpecial(args []string) {
 // SHOW
 // ---------------------------------------------------------------------------------------
 func cmdShow(args []string) {
-	if len(args) != 1 {
+	if len(args) < 1 || len(args) > 2 {
 		utils.Config.Log.LogError("Invalid command provided")
 		return
 	}
-	what, _ := utils.ParseNextArg(args)
+	what, args := utils.ParseNextArg(args)
 	switch what {
 	case "targets":
 		ShowTargets()
@@ -327,9 +392,33 @@ func cmdShow(args []string) {
 		ShowHosts()
 	case "ports":
 		ShowPorts()
+	case "windows":
+		ShowWindows()
+	case "recipes":
+		ShowRecipes()
+	case "details":
+		ShowDetails(optionalArg(args))
+	case "findings":
+		ShowFindings(optionalArg(args))
+	case "web":
+		ShowWeb(optionalArg(args))
+	case "shares":
+		ShowShares(optionalArg(args))
+	case "creds":
+		ShowCreds(optionalArg(args))
+	case "inventory":
+		ShowInventory(optionalArg(args))
 	}
 }
 
+// Optional argument of a command (e.g., the host of "show details [<HOST>]")
+func optionalArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
 func ShowTargets() {
 	if !utils.IsDBAvailable() {
 		utils.Config.Log.LogWarning("Database not available - cannot show targets")
@@ -368,7 +457,7 @@ func ShowHosts() {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Address", "Status", "OS", "Info", "Ports"})
+	table.SetHeader([]string{"Address", "Status", "OS", "Info", "Domain", "Hostnames", "Ports"})
 	table.SetRowLine(true)
 	table.SetAlignment(3)
 	table.SetAutoWrapText(false)
@@ -378,6 +467,8 @@ func ShowHosts() {
 		rStatus := h.Status
 		rOS := h.OS
 		rInfo := h.Info
+		rDomain := h.Domain
+		rHostnames := strings.Replace(h.Hostnames, ",", "\n", -1)
 		rPorts := ""
 		for _, tPort := range h.GetPorts(utils.Config.DB) {
 			tService := tPort.GetService(utils.Config.DB)
@@ -388,7 +479,7 @@ func ShowHosts() {
 				rPorts = fmt.Sprintf("%s\n", rPorts)
 			}
 		}
-		v := []string{rAddress, rStatus, rOS, rInfo, rPorts}
+		v := []string{rAddress, rStatus, rOS, rInfo, rDomain, rHostnames, rPorts}
 		table.Append(v)
 	}
 	table.Render()
@@ -432,6 +523,548 @@ func ShowPorts() {
 	table.Render()
 }
 
+func ShowWindows() {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show windows")
+		return
+	}
+	windows := model.GetAllWindows(utils.Config.DB)
+	if len(windows) == 0 {
+		utils.Config.Log.LogInfo("No scanning windows defined, scans are allowed at any time")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "Start (UTC)", "End (UTC)", "On Close", "Active Now"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	now := time.Now()
+	for _, w := range windows {
+		rActive := "no"
+		if w.Contains(now) {
+			rActive = "yes"
+		}
+		v := []string{w.Kind, w.Start, w.End, w.Action, rActive}
+		table.Append(v)
+	}
+	table.Render()
+
+	if open, why, _ := scan.CheckWindow(now); !open {
+		utils.Config.Log.LogWarning(fmt.Sprintf("Scanning is currently not allowed: %s", why))
+	}
+}
+
+func ShowDetails(address string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show details")
+		return
+	}
+	details := []model.Detail{}
+	if address == "" {
+		details = model.GetAllDetails(utils.Config.DB)
+	} else {
+		host := model.GetHostByAddress(utils.Config.DB, address)
+		if host.ID == 0 {
+			utils.Config.Log.LogError(fmt.Sprintf("Host not found: %s", address))
+			return
+		}
+		details = host.GetDetails(utils.Config.DB)
+	}
+	if len(details) == 0 {
+		utils.Config.Log.LogInfo("No details extracted yet, run the enumeration first")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Host", "Port", "Source", "Key", "Value"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	hosts := map[uint]string{}
+	for _, d := range details {
+		if _, ok := hosts[d.HostID]; !ok {
+			hosts[d.HostID] = d.GetHost(utils.Config.DB).Address
+		}
+		rPort := ""
+		if d.Port != 0 {
+			rPort = strconv.Itoa(d.Port)
+		}
+		v := []string{hosts[d.HostID], rPort, d.Source, d.Key, d.Value}
+		table.Append(v)
+	}
+	table.Render()
+}
+
+func ShowFindings(address string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show findings")
+		return
+	}
+	findings := []model.Finding{}
+	if address == "" {
+		findings = model.GetAllFindings(utils.Config.DB)
+	} else {
+		host := model.GetHostByAddress(utils.Config.DB, address)
+		if host.ID == 0 {
+			utils.Config.Log.LogError(fmt.Sprintf("Host not found: %s", address))
+			return
+		}
+		findings = host.GetFindings(utils.Config.DB)
+	}
+	if len(findings) == 0 {
+		utils.Config.Log.LogInfo("No findings")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Severity", "Host", "Port", "Title", "Detail", "Source"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	hosts := map[uint]string{}
+	for _, f := range findings {
+		if _, ok := hosts[f.HostID]; !ok {
+			hosts[f.HostID] = f.GetHost(utils.Config.DB).Address
+		}
+		rPort := ""
+		if f.Port != 0 {
+			rPort = strconv.Itoa(f.Port)
+		}
+		v := []string{f.Severity, hosts[f.HostID], rPort, f.Title, f.Detail, f.Source}
+		table.Append(v)
+	}
+	table.Render()
+}
+
+func ShowWeb(address string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show web services")
+		return
+	}
+	hosts := model.GetAllHosts(utils.Config.DB)
+	if address != "" {
+		host := model.GetHostByAddress(utils.Config.DB, address)
+		if host.ID == 0 {
+			utils.Config.Log.LogError(fmt.Sprintf("Host not found: %s", address))
+			return
+		}
+		hosts = []model.Host{*host}
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Host", "Port", "URL", "Status", "Title", "Server", "Technologies", "Missing Headers", "Favicon", "Certificate", "URLs"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	// Discovered URLs are listed when showing a single host
+	urlTable := tablewriter.NewWriter(os.Stdout)
+	urlTable.SetHeader([]string{"Port", "URL", "Status", "Size", "Source"})
+	urlTable.SetAlignment(3)
+	urlTable.SetAutoWrapText(false)
+
+	found, urls := 0, 0
+	for _, h := range hosts {
+		for _, tPort := range h.GetPorts(utils.Config.DB) {
+			tService := tPort.GetService(utils.Config.DB)
+			if tService.ID == 0 {
+				continue
+			}
+			discovered := tService.GetWebURLs(utils.Config.DB)
+			for _, u := range discovered {
+				rStatus, rSize := "", ""
+				if u.StatusCode != 0 {
+					rStatus, rSize = strconv.Itoa(u.StatusCode), strconv.Itoa(u.Size)
+				}
+				urlTable.Append([]string{strconv.Itoa(tPort.Number), u.URL, rStatus, rSize, u.Source})
+				urls++
+			}
+			w := tService.GetWeb(utils.Config.DB)
+			if w.ID == 0 {
+				continue
+			}
+			rURL := w.URL
+			if w.Redirects != "" {
+				rURL = strings.Replace(w.Redirects, " -> ", "\n-> ", -1)
+			}
+			rCert := ""
+			if w.CertSubject != "" {
+				rCert = fmt.Sprintf("%s\nIssuer: %s\nExpires: %s", w.CertSubject, w.CertIssuer, w.CertNotAfter)
+				if w.CertSANs != "" {
+					rCert = fmt.Sprintf("%s\nSANs: %s", rCert, w.CertSANs)
+				}
+			}
+			v := []string{h.Address, strconv.Itoa(tPort.Number), rURL, strconv.Itoa(w.StatusCode), w.Title, w.Server,
+				strings.Replace(w.Technologies, ", ", "\n", -1), strings.Replace(w.MissingHeaders, ", ", "\n", -1), w.FaviconHash, rCert,
+				strconv.Itoa(len(discovered))}
+			table.Append(v)
+			found++
+		}
+	}
+	if found == 0 && urls == 0 {
+		utils.Config.Log.LogInfo("No web services fingerprinted yet, run the HTTP enumeration first")
+		return
+	}
+	if found > 0 {
+		table.Render()
+	}
+	if address == "" {
+		return
+	}
+	if urls > 0 {
+		utils.Config.Log.LogInfo(fmt.Sprintf("Discovered URLs (%d):", urls))
+		urlTable.Render()
+	}
+	// Web findings (nikto, sqlmap, ...)
+	findings := [][]string{}
+	for _, f := range hosts[0].GetFindings(utils.Config.DB) {
+		if f.Source == "HTTP" {
+			findings = append(findings, []string{strconv.Itoa(f.Port), f.Severity, f.Title, f.Detail})
+		}
+	}
+	if len(findings) > 0 {
+		utils.Config.Log.LogInfo(fmt.Sprintf("Web findings (%d):", len(findings)))
+		fTable := tablewriter.NewWriter(os.Stdout)
+		fTable.SetHeader([]string{"Port", "Severity", "Title", "Detail"})
+		fTable.SetRowLine(true)
+		fTable.SetAlignment(3)
+		fTable.SetAutoWrapText(false)
+		fTable.AppendBulk(findings)
+		fTable.Render()
+	}
+}
+
+func ShowShares(address string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show shares")
+		return
+	}
+	shares := []model.Share{}
+	if address == "" {
+		shares = model.GetAllShares(utils.Config.DB)
+	} else {
+		host := model.GetHostByAddress(utils.Config.DB, address)
+		if host.ID == 0 {
+			utils.Config.Log.LogError(fmt.Sprintf("Host not found: %s", address))
+			return
+		}
+		shares = host.GetShares(utils.Config.DB)
+	}
+	if len(shares) == 0 {
+		utils.Config.Log.LogInfo("No shares enumerated yet, run the SMB enumeration first")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Host", "Share", "Type", "Comment", "Access"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	hosts := map[uint]string{}
+	for _, sh := range shares {
+		if _, ok := hosts[sh.HostID]; !ok {
+			hosts[sh.HostID] = sh.GetHost(utils.Config.DB).Address
+		}
+		v := []string{hosts[sh.HostID], sh.Name, sh.Type, sh.Comment, sh.Access}
+		table.Append(v)
+	}
+	table.Render()
+}
+
+func ShowCreds(address string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show credentials")
+		return
+	}
+	creds := []model.Credential{}
+	if address == "" {
+		creds = model.GetAllCredentials(utils.Config.DB)
+	} else {
+		host := model.GetHostByAddress(utils.Config.DB, address)
+		if host.ID == 0 {
+			utils.Config.Log.LogError(fmt.Sprintf("Host not found: %s", address))
+			return
+		}
+		creds = host.GetCredentials(utils.Config.DB)
+	}
+	if len(creds) == 0 {
+		utils.Config.Log.LogInfo("No credentials found yet")
+		return
+	}
+	if !utils.VaultUnlocked() {
+		utils.Config.Log.LogInfo("Vault locked, secrets are hidden (set passphrase <PASSPHRASE>)")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Host", "Port", "Service", "Username", "Secret", "Source", "Verified"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	hosts := map[uint]string{}
+	for _, c := range creds {
+		if _, ok := hosts[c.HostID]; !ok {
+			hosts[c.HostID] = c.GetHost(utils.Config.DB).Address
+		}
+		v := []string{hosts[c.HostID], strconv.Itoa(c.Port), c.Service, c.Username, showSecret(c), c.Source, strconv.FormatBool(c.Verified)}
+		table.Append(v)
+	}
+	table.Render()
+}
+
+func ShowInventory(address string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot show the inventory")
+		return
+	}
+	if address == "" {
+		utils.Config.Log.LogError("Invalid command provided: show inventory <HOST>")
+		return
+	}
+	host := model.GetHostByAddress(utils.Config.DB, address)
+	if host.ID == 0 {
+		utils.Config.Log.LogError(fmt.Sprintf("Host not found: %s", address))
+		return
+	}
+	processes := host.GetProcesses(utils.Config.DB)
+	software := host.GetSoftware(utils.Config.DB)
+	listeners := host.GetListeners(utils.Config.DB)
+	interfaces := host.GetInterfaces(utils.Config.DB)
+	if len(processes)+len(software)+len(listeners)+len(interfaces) == 0 {
+		utils.Config.Log.LogInfo("No inventory collected yet, run the SNMP enumeration first")
+		return
+	}
+
+	newTable := func(title string, count int, header []string) *tablewriter.Table {
+		utils.Config.Log.LogInfo(fmt.Sprintf("%s (%d):", title, count))
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(header)
+		table.SetAlignment(3)
+		table.SetAutoWrapText(false)
+		return table
+	}
+	if len(interfaces) > 0 {
+		table := newTable("Interfaces", len(interfaces), []string{"Index", "Name", "MAC", "Addresses"})
+		for _, i := range interfaces {
+			table.Append([]string{strconv.Itoa(i.Index), i.Name, i.MAC, strings.Replace(i.Addresses, ",", "\n", -1)})
+		}
+		table.Render()
+	}
+	if len(listeners) > 0 {
+		table := newTable("Listening ports", len(listeners), []string{"Protocol", "Address", "Port"})
+		for _, l := range listeners {
+			table.Append([]string{l.Protocol, l.Address, strconv.Itoa(l.Port)})
+		}
+		table.Render()
+	}
+	if len(processes) > 0 {
+		table := newTable("Processes", len(processes), []string{"PID", "Name", "Path", "Arguments"})
+		for _, p := range processes {
+			table.Append([]string{strconv.Itoa(p.PID), p.Name, p.Path, p.Args})
+		}
+		table.Render()
+	}
+	if len(software) > 0 {
+		table := newTable("Installed software", len(software), []string{"Name"})
+		for _, sw := range software {
+			table.Append([]string{sw.Name})
+		}
+		table.Render()
+	}
+}
+
+// Secret of a credential as shown to the operator
+func showSecret(c model.Credential) string {
+	if c.Secret == "" {
+		return "(not stored)"
+	}
+	if !utils.VaultUnlocked() {
+		return "(locked)"
+	}
+	secret, err := utils.Decrypt(c.Secret)
+	if err != nil {
+		return "(cannot decrypt)"
+	}
+	if secret == "" {
+		return "<empty>"
+	}
+	return secret
+}
+
+func ShowRecipes() {
+	recipes := enum.GetRecipes()
+	if len(recipes) == 0 {
+		utils.Config.Log.LogInfo("No enumeration recipes available")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "Services", "Steps", "Source"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	for _, kind := range enum.RecipeKinds(recipes) {
+		r := recipes[kind]
+		rServices, rSteps := "", ""
+		for _, srv := range r.Services {
+			rServices = fmt.Sprintf("%s%s\n", rServices, srv.Name)
+			for _, st := range srv.Steps {
+				step := st.Name
+				if st.Politeness == enum.POLITENESS_AGGRESSIVE {
+					step = fmt.Sprintf("%s (%s)", step, st.Politeness)
+				}
+				rSteps = fmt.Sprintf("%s%s/%s\n", rSteps, srv.Name, step)
+			}
+		}
+		v := []string{kind, rServices, rSteps, r.Source}
+		table.Append(v)
+	}
+	table.Render()
+}
+
+// ---------------------------------------------------------------------------------------
+// JOBS
+// ---------------------------------------------------------------------------------------
+func cmdJobs() {
+	if len(scan.ScansList) == 0 && len(enum.EnumList) == 0 {
+		utils.Config.Log.LogInfo("No jobs running")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Job", "Target", "Status", "Reason"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+
+	paused, pausedReason := scan.WindowPaused()
+	status := func(s int, reason string) (string, string) {
+		if s == model.IN_PROGRESS && paused {
+			return "PAUSED", pausedReason
+		}
+		return model.StatusString(s), reason
+	}
+	for _, s := range scan.ScansList {
+		rStatus, rReason := status(s.Status, s.Reason)
+		v := []string{strconv.Itoa(s.ID), s.Name, s.Target, rStatus, rReason}
+		table.Append(v)
+	}
+	for _, e := range enum.EnumList {
+		rStatus, rReason := status(e.Status, e.Reason)
+		v := []string{strconv.Itoa(e.ID), fmt.Sprintf("enumerate %s %s", e.Kind, e.Polite), e.Target.Address, rStatus, rReason}
+		table.Append(v)
+	}
+	table.Render()
+}
+
+// ---------------------------------------------------------------------------------------
+// AUDIT
+// ---------------------------------------------------------------------------------------
+func cmdAudit(args []string) {
+	if len(args) == 0 {
+		utils.Config.Log.LogError("Invalid command provided")
+		return
+	}
+	what, args := utils.ParseNextArg(args)
+	switch what {
+	case "verify":
+		entries, err := utils.ReadAudit(utils.AuditPath())
+		if err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Cannot read audit log: %s", err))
+			return
+		}
+		if err := utils.VerifyAudit(entries); err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Audit log failed verification: %s", err))
+			return
+		}
+		utils.Config.Log.LogNotify(fmt.Sprintf("Audit log verified: %d entries (%s)", len(entries), utils.AuditPath()))
+	case "export":
+		if len(args) != 1 {
+			utils.Config.Log.LogError("Please provide the destination file")
+			return
+		}
+		dst, _ := utils.ParseNextArg(args)
+		count, err := utils.ExportAudit(dst)
+		if err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Cannot export audit log: %s", err))
+			return
+		}
+		utils.Config.Log.LogNotify(fmt.Sprintf("Exported %d audit entries to: %s", count, dst))
+	default:
+		utils.Config.Log.LogError("Invalid command provided")
+	}
+}
+
+// ---------------------------------------------------------------------------------------
+// CREDENTIALS
+// ---------------------------------------------------------------------------------------
+func cmdCreds(args []string) {
+	if len(args) == 0 {
+		utils.Config.Log.LogError("Invalid command provided")
+		return
+	}
+	what, args := utils.ParseNextArg(args)
+	switch what {
+	case "export":
+		if len(args) != 1 {
+			utils.Config.Log.LogError("Please provide the destination file")
+			return
+		}
+		dst, _ := utils.ParseNextArg(args)
+		count, err := ExportCreds(dst)
+		if err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Cannot export credentials: %s", err))
+			return
+		}
+		utils.Config.Log.LogNotify(fmt.Sprintf("Exported %d credentials to: %s", count, dst))
+	default:
+		utils.Config.Log.LogError("Invalid command provided")
+	}
+}
+
+// Export the credentials as CSV, with their secrets decrypted (readable by the owner only).
+// The "stored" column tells an empty password apart from a secret dropped while the vault
+// was locked
+func ExportCreds(dst string) (int, error) {
+	if !utils.IsDBAvailable() {
+		return 0, fmt.Errorf("database not available")
+	}
+	if !utils.VaultUnlocked() {
+		return 0, utils.ErrVaultLocked
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"host", "port", "service", "username", "secret", "stored", "source", "verified"})
+	hosts := map[uint]string{}
+	creds := model.GetAllCredentials(utils.Config.DB)
+	for _, c := range creds {
+		if _, ok := hosts[c.HostID]; !ok {
+			hosts[c.HostID] = c.GetHost(utils.Config.DB).Address
+		}
+		secret := ""
+		if c.Secret != "" {
+			if secret, err = utils.Decrypt(c.Secret); err != nil {
+				return 0, err
+			}
+		}
+		stored := strconv.FormatBool(c.Secret != "")
+		w.Write([]string{hosts[c.HostID], strconv.Itoa(c.Port), c.Service, c.Username, secret, stored, c.Source, strconv.FormatBool(c.Verified)})
+	}
+	w.Flush()
+	return len(creds), w.Error()
+}
+
 // ---------------------------------------------------------------------------------------
 // UTILS
 // ---------------------------------------------------------------------------------------
@@ -471,6 +1104,8 @@ func cmdSet(args []string) {
 	case "output_folder":
 		folder, _ := utils.ParseNextArg(args)
 		utils.ChangeOutFolder(folder)
+		// The user recipes live in the output folder
+		enum.ReloadRecipes()
 	case "nmap_switches":
 		// Get kind
 		kind, args := utils.ParseNextArg(args)
@@ -498,6 +1133,14 @@ func cmdSet(args []string) {
 			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Const_NMAP_TCP_VULN))
 			utils.Const_NMAP_TCP_VULN = switches
 			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.Const_NMAP_TCP_VULN))
+		case "TCP_DISCOVERY":
+			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Const_NMAP_TCP_DISCOVERY))
+			utils.Const_NMAP_TCP_DISCOVERY = switches
+			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.Const_NMAP_TCP_DISCOVERY))
+		case "TCP_SERVICES":
+			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Const_NMAP_TCP_SERVICES))
+			utils.Const_NMAP_TCP_SERVICES = switches
+			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.Const_NMAP_TCP_SERVICES))
 		case "UDP_STANDARD":
 			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Const_NMAP_UDP_STANDARD))
 			utils.Const_NMAP_UDP_STANDARD = switches
@@ -506,7 +1149,40 @@ func cmdSet(args []string) {
 			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Const_NMAP_UDP_PROD))
 			utils.Const_NMAP_UDP_PROD = switches
 			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.Const_NMAP_UDP_PROD))
+		case "ICS":
+			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Const_NMAP_ICS))
+			utils.Const_NMAP_ICS = switches
+			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.Const_NMAP_ICS))
 		}
+	case "recipes_folder":
+		folder, _ := utils.ParseNextArg(args)
+		utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.Config.RecipesFolder))
+		utils.Config.RecipesFolder = folder
+		utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.Config.RecipesFolder))
+		utils.Config.Log.LogInfo(fmt.Sprintf("Enumeration recipes available: %s", strings.Join(enum.RecipeKinds(enum.ReloadRecipes()), ", ")))
+	case "window":
+		setWindow(args)
+	case "passphrase":
+		passphrase := utils.ParseAllArgs(args)
+		if err := utils.UnlockVault(passphrase); err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Cannot unlock the vault: %s", err))
+			return
+		}
+		utils.Config.Log.LogNotify("Vault unlocked")
+	case "snmp_v3":
+		setSNMPv3(args)
+	case "engine":
+		engine := optionalArg(args)
+		switch strings.ToLower(engine) {
+		case utils.ENGINE_NMAP, utils.ENGINE_NATIVE:
+			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.ENGINE))
+			utils.ENGINE = strings.ToLower(engine)
+			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.ENGINE))
+		default:
+			utils.Config.Log.LogError("Invalid engine provided: NMAP or NATIVE")
+		}
+	case "native_scan":
+		setNativeScan(args)
 	case "wordlists":
 		// Get kind
 		kind, args := utils.ParseNextArg(args)
@@ -558,6 +1234,123 @@ func cmdSet(args []string) {
 			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.WORDLIST_HYDRA_FTP_PWD))
 			utils.WORDLIST_HYDRA_FTP_PWD = switches
 			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.WORDLIST_HYDRA_FTP_PWD))
+
+		case "KERBEROS_USER":
+			utils.Config.Log.LogInfo(fmt.Sprintf("Previous value: %s", utils.WORDLIST_KERBEROS_USER))
+			utils.WORDLIST_KERBEROS_USER = switches
+			utils.Config.Log.LogNotify(fmt.Sprintf("Updated value: %s", utils.WORDLIST_KERBEROS_USER))
+		}
+	}
+}
+
+// set native_scan <PORTS/TIMEOUT/RETRIES/RATE/WORKERS/BANNERS/SWEEP_PORTS> <VALUE>
+func setNativeScan(args []string) {
+	if len(args) != 2 {
+		utils.Config.Log.LogError("Invalid command provided: set native_scan <PORTS/TIMEOUT/RETRIES/RATE/WORKERS/BANNERS/SWEEP_PORTS> <VALUE>")
+		return
+	}
+	option, value := args[0], args[1]
+	opts := utils.NATIVE_SCAN
+	switch option {
+	case "PORTS":
+		opts.Ports = value
+	case "SWEEP_PORTS":
+		opts.SweepPorts = value
+	case "BANNERS":
+		if value != "ON" && value != "OFF" {
+			utils.Config.Log.LogError(fmt.Sprintf("Invalid value: %s (ON/OFF)", value))
+			return
+		}
+		opts.Banners = value == "ON"
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || (n == 0 && option != "RETRIES") {
+			utils.Config.Log.LogError(fmt.Sprintf("Invalid value: %s", value))
+			return
+		}
+		switch option {
+		case "TIMEOUT":
+			opts.Timeout = time.Duration(n) * time.Millisecond
+		case "RETRIES":
+			opts.Retries = n
+		case "RATE":
+			if n > utils.NATIVE_MAX_RATE {
+				utils.Config.Log.LogError(fmt.Sprintf("Invalid value: %s (at most %d probes/s)", value, utils.NATIVE_MAX_RATE))
+				return
+			}
+			opts.Rate = n
+		case "WORKERS":
+			opts.Workers = n
+		default:
+			utils.Config.Log.LogError("Invalid option provided: PORTS, TIMEOUT, RETRIES, RATE, WORKERS, BANNERS or SWEEP_PORTS")
+			return
 		}
 	}
+	utils.NATIVE_SCAN = opts
+	utils.Config.Log.LogNotify(fmt.Sprintf("Native scanner: ports %s, timeout %s, %d retries, %d probes/s, %d workers, banners %t, sweep ports %s",
+		nativePorts(opts.Ports), opts.Timeout, opts.Retries, opts.Rate, opts.Workers, opts.Banners, opts.SweepPorts))
+}
+
+func nativePorts(ports string) string {
+	if ports == "" {
+		return "(from the profile)"
+	}
+	return ports
+}
+
+// set snmp_v3 <USER> [<AUTH_PROTOCOL> <AUTH_PASS> [<PRIV_PROTOCOL> <PRIV_PASS>]] | CLEAR
+func setSNMPv3(args []string) {
+	if len(args) == 1 && args[0] == "CLEAR" {
+		utils.SNMP_V3 = utils.SNMPv3Credentials{}
+		utils.Config.Log.LogNotify("SNMPv3 credentials removed")
+		return
+	}
+	if len(args) != 1 && len(args) != 3 && len(args) != 5 {
+		utils.Config.Log.LogError("Invalid command provided: set snmp_v3 <USER> [<AUTH_PROTOCOL> <AUTH_PASS> [<PRIV_PROTOCOL> <PRIV_PASS>]]")
+		return
+	}
+	creds := utils.SNMPv3Credentials{User: args[0]}
+	if len(args) >= 3 {
+		creds.AuthProtocol, creds.AuthPassphrase = strings.ToUpper(args[1]), args[2]
+	}
+	if len(args) == 5 {
+		creds.PrivProtocol, creds.PrivPassphrase = strings.ToUpper(args[3]), args[4]
+	}
+	utils.SNMP_V3 = creds
+	utils.Config.Log.LogNotify(fmt.Sprintf("SNMPv3 credentials set for user %s", creds.User))
+}
+
+// Add (or clear) the scanning windows of the workspace
+func setWindow(args []string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - scanning windows cannot be persisted")
+		return
+	}
+	if len(args) == 1 && args[0] == "CLEAR" {
+		model.DeleteAllWindows(utils.Config.DB)
+		utils.Config.Log.LogNotify("Removed all scanning windows")
+		return
+	}
+	if len(args) != 4 {
+		utils.Config.Log.LogError("Invalid command provided")
+		return
+	}
+	kind, args := utils.ParseNextArg(args)
+	start, args := utils.ParseNextArg(args)
+	end, args := utils.ParseNextArg(args)
+	action, _ := utils.ParseNextArg(args)
+	if kind != model.WINDOW_ALLOW && kind != model.WINDOW_BLACKOUT {
+		utils.Config.Log.LogError(fmt.Sprintf("Invalid kind of window: %s", kind))
+		return
+	}
+	if action != model.WINDOW_ACTION_PAUSE && action != model.WINDOW_ACTION_CANCEL {
+		utils.Config.Log.LogError(fmt.Sprintf("Invalid action: %s", action))
+		return
+	}
+	w, err := model.AddWindow(utils.Config.DB, kind, start, end, action)
+	if err != nil {
+		utils.Config.Log.LogError(fmt.Sprintf("Cannot add window: %s", err))
+		return
+	}
+	utils.Config.Log.LogNotify(fmt.Sprintf("Added scanning window: %s", w.String()))
 }
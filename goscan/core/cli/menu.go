@@ -58,9 +58,10 @@ var fullMenuItems = []MenuItem{
 	{9, "Gaming Console Scans", "Gaming device discovery and scanning", handleGamingScans},
 	{10, "IoT Device Scans", "Internet of Things device scanning", handleIoTScans},
 	{11, "CCTV & Drone Scans", "Surveillance and drone device scanning", handleCCTVScans},
-	{12, "MAC Address Analysis", "MAC address and vendor identification", handleMACAnalysis},
-	{13, "View Scan Logs", "View previous scan results and logs", handleVirusScanLogs},
-	{14, "View Scan Cache", "Access cached scan results", handleScanCache},
+	{12, "ICS / OT Scans", "Industrial protocol discovery (read-only probes)", handleICSScans},
+	{13, "MAC Address Analysis", "MAC address and vendor identification", handleMACAnalysis},
+	{14, "View Scan Logs", "View previous scan results and logs", handleVirusScanLogs},
+	{15, "View Scan Cache", "Access cached scan results", handleScanCache},
 }
 
 // Primary menu shows top scans plus utilities and navigation
@@ -84,7 +85,7 @@ func PrintBanner() {
 	fmt.Print(boldGreen("╔"))
 	for i := 0; i < 70; i++ {
 		fmt.Print(boldGreen("═"))
-		time.Sleep(2 * time.Millisecond)
+		utils.AnimationDelay(2 * time.Millisecond)
 	}
 	fmt.Println(boldGreen("╗"))
 
@@ -98,7 +99,7 @@ func PrintBanner() {
 	fmt.Print(boldGreen("╚"))
 	for i := 0; i < 70; i++ {
 		fmt.Print(boldGreen("═"))
-		time.Sleep(2 * time.Millisecond)
+		utils.AnimationDelay(2 * time.Millisecond)
 	}
 	fmt.Println(boldGreen("╝"))
 
@@ -121,7 +122,7 @@ func PrintMainMenu() {
 	fmt.Print(boldCyan("╔"))
 	for i := 0; i < 66; i++ {
 		fmt.Print(boldCyan("═"))
-		time.Sleep(1 * time.Millisecond)
+		utils.AnimationDelay(1 * time.Millisecond)
 	}
 	fmt.Println(boldCyan("╗"))
 
@@ -130,7 +131,7 @@ func PrintMainMenu() {
 	fmt.Print(boldCyan("╚"))
 	for i := 0; i < 66; i++ {
 		fmt.Print(boldCyan("═"))
-		time.Sleep(1 * time.Millisecond)
+		utils.AnimationDelay(1 * time.Millisecond)
 	}
 	fmt.Println(boldCyan("╝"))
 
@@ -150,7 +151,7 @@ func PrintMainMenu() {
 
 		// Add slight delay for smooth animation
 		if i < len(primaryMenuItems)-1 {
-			time.Sleep(30 * time.Millisecond)
+			utils.AnimationDelay(30 * time.Millisecond)
 		}
 	}
 	fmt.Print("\n")
@@ -164,7 +165,7 @@ func PrintFullMenu() {
 	fmt.Print(boldCyan("╔"))
 	for i := 0; i < 66; i++ {
 		fmt.Print(boldCyan("═"))
-		time.Sleep(1 * time.Millisecond)
+		utils.AnimationDelay(1 * time.Millisecond)
 	}
 	fmt.Println(boldCyan("╗"))
 
@@ -173,7 +174,7 @@ func PrintFullMenu() {
 	fmt.Print(boldCyan("╚"))
 	for i := 0; i < 66; i++ {
 		fmt.Print(boldCyan("═"))
-		time.Sleep(1 * time.Millisecond)
+		utils.AnimationDelay(1 * time.Millisecond)
 	}
 	fmt.Println(boldCyan("╝"))
 
@@ -182,7 +183,7 @@ func PrintFullMenu() {
 	// Animated menu items
 	for idx, item := range fullMenuItems {
 		fmt.Printf("%s [%2d] %s - %s\n", colorGreen("●"), idx+1, boldGreen(item.Title), colorWhite(item.Description))
-		time.Sleep(30 * time.Millisecond)
+		utils.AnimationDelay(30 * time.Millisecond)
 	}
 	fmt.Printf("%s [%2d] %s - %s\n", colorRed("●"), 0, boldRed("Back"), colorRed("Return to main menu"))
 	fmt.Print("\n")
@@ -268,8 +269,14 @@ func handleSecurityScans() {
 	fmt.Printf("\n%s Enter target IP: ", colorCyan("▶"))
 	target, _ := reader.ReadString('\n')
 	target = strings.TrimSpace(target)
-
-	if target != "" {
+	fmt.Printf("%s SSL/TLS analysis only (requires a previous port scan)? [y/N]: ", colorCyan("▶"))
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	if target != "" && (answer == "y" || answer == "yes") {
+		fmt.Printf("\n%s Starting SSL/TLS analysis on %s\n", colorGreen("✓"), colorYellow(target))
+		ExecuteTLSScan(target)
+	} else if target != "" {
 		fmt.Printf("\n%s Starting security scan on %s\n", colorGreen("✓"), colorYellow(target))
 		ExecuteSecurityScan(target)
 	}
@@ -455,8 +462,27 @@ func handleCCTVScans() {
 	}
 }
 
+func handleICSScans() {
+	fmt.Println(boldCyan("\n[12] ICS / OT SCANS"))
+	fmt.Println(colorCyan("─────────────────────────────────────────────────────────────────\n"))
+	fmt.Println(colorGreen("▸ Modbus / S7 / EtherNet/IP / DNP3 Discovery") + " - Find PLCs and RTUs")
+	fmt.Println(colorGreen("▸ BACnet Discovery") + " - Find building automation controllers")
+	fmt.Println(colorGreen("▸ Device Identification") + " - Vendor, model and firmware (read-only)")
+	utils.WarningBanner("ICS / OT DEVICES", utils.Const_ICS_WARNING)
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("\n%s Enter target IP: ", colorCyan("▶"))
+	target, _ := reader.ReadString('\n')
+	target = strings.TrimSpace(target)
+
+	if target != "" {
+		fmt.Printf("\n%s Starting ICS scan on %s\n", colorGreen("✓"), colorYellow(target))
+		ExecuteICSScan(target)
+	}
+}
+
 func handleMACAnalysis() {
-	fmt.Println(boldCyan("\n[12] MAC ADDRESS ANALYSIS"))
+	fmt.Println(boldCyan("\n[13] MAC ADDRESS ANALYSIS"))
 	fmt.Println(colorCyan("─────────────────────────────────────────────────────────────────\n"))
 	fmt.Println(colorGreen("▸ Vendor Lookup") + " - Identify MAC address vendor")
 	fmt.Println(colorGreen("▸ Local Network Analysis") + " - Analyze all MAC addresses on network")
@@ -474,7 +500,7 @@ func handleMACAnalysis() {
 }
 
 func handleVirusScanLogs() {
-	fmt.Println(boldCyan("\n[13] VIRUS SCAN LOGS"))
+	fmt.Println(boldCyan("\n[14] VIRUS SCAN LOGS"))
 	fmt.Println(colorCyan("─────────────────────────────────────────────────────────────────\n"))
 	fmt.Println(colorGreen("▸ View Scan History") + " - Display previous scans")
 	fmt.Println(colorGreen("▸ Threat Analysis") + " - Analyze detected threats")
@@ -485,7 +511,7 @@ func handleVirusScanLogs() {
 }
 
 func handleScanCache() {
-	fmt.Println(boldCyan("\n[14] VIEW SCAN CACHE"))
+	fmt.Println(boldCyan("\n[15] VIEW SCAN CACHE"))
 	fmt.Println(colorCyan("─────────────────────────────────────────────────────────────────\n"))
 	fmt.Println(colorGreen("▸ Database Cache") + " - View cached results")
 	fmt.Println(colorGreen("▸ Import Cache") + " - Import cached data")
@@ -499,6 +525,12 @@ func handleScanCache() {
 // EXECUTION FUNCTIONS
 // ═══════════════════════════════════════════════════════════════════════════════════════
 
+// runMenuCommand runs a CLI command on behalf of a menu selection, recording it in the audit log
+func runMenuCommand(fn func([]string), cmd string, args ...string) {
+	utils.AuditAction(strings.Join(append([]string{cmd}, args...), " "))
+	fn(args)
+}
+
 func ExecuteSweep(target string) {
 	fmt.Printf("%s [SWEEP] Performing ping sweep on %s...\n", colorGreen("►"), colorYellow(target))
 	// Ensure target is tracked when a database is available
@@ -506,7 +538,7 @@ func ExecuteSweep(target string) {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
 	// Call the actual nmap sweep function using the expected keyword
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func ExecuteSecurityScan(target string) {
@@ -514,7 +546,15 @@ func ExecuteSecurityScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddHost(utils.Config.DB, target, "up", model.NEW.String())
 	}
-	cmdPortscan([]string{"TCP-VULN-SCAN", target})
+	runMenuCommand(cmdPortscan, "portscan", "TCP-VULN-SCAN", target)
+}
+
+func ExecuteTLSScan(target string) {
+	fmt.Printf("%s [TLS] Analysing SSL/TLS services on %s...\n", colorGreen("►"), colorYellow(target))
+	if utils.IsDBAvailable() {
+		model.AddHost(utils.Config.DB, target, "up", model.NEW.String())
+	}
+	runMenuCommand(cmdEnumerate, "enumerate", "TLS", "POLITE", target)
 }
 
 func ExecuteNetworkDiscovery(target string) {
@@ -522,7 +562,7 @@ func ExecuteNetworkDiscovery(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func ExecuteTCPScan(target string) {
@@ -530,7 +570,7 @@ func ExecuteTCPScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddHost(utils.Config.DB, target, "up", model.NEW.String())
 	}
-	cmdPortscan([]string{"TCP-FULL", target})
+	runMenuCommand(cmdPortscan, "portscan", "TCP-FULL", target)
 }
 
 func ExecuteARPScan(target string) {
@@ -538,7 +578,7 @@ func ExecuteARPScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func ExecuteAuthScan(target string) {
@@ -546,7 +586,7 @@ func ExecuteAuthScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddHost(utils.Config.DB, target, "up", model.NEW.String())
 	}
-	cmdEnumerate([]string{"SSH", "POLITE", target})
+	runMenuCommand(cmdEnumerate, "enumerate", "SSH", "POLITE", target)
 }
 
 func ExecuteWebAppScan(target string) {
@@ -554,7 +594,7 @@ func ExecuteWebAppScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddHost(utils.Config.DB, target, "up", model.NEW.String())
 	}
-	cmdEnumerate([]string{"HTTP", "POLITE", target})
+	runMenuCommand(cmdEnumerate, "enumerate", "HTTP", "POLITE", target)
 }
 
 func ExecuteMobileScan(target string) {
@@ -562,7 +602,7 @@ func ExecuteMobileScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func ExecuteGamingScan(target string) {
@@ -570,7 +610,7 @@ func ExecuteGamingScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func ExecuteIoTScan(target string) {
@@ -578,7 +618,7 @@ func ExecuteIoTScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func ExecuteCCTVScan(target string) {
@@ -586,7 +626,15 @@ func ExecuteCCTVScan(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
+}
+
+func ExecuteICSScan(target string) {
+	fmt.Printf("%s [ICS] Performing ICS device discovery on %s...\n", colorGreen("►"), colorYellow(target))
+	if utils.IsDBAvailable() {
+		model.AddHost(utils.Config.DB, target, "up", model.NEW.String())
+	}
+	runMenuCommand(cmdPortscan, "portscan", "ICS", target)
 }
 
 func ExecuteMACAnalysis(target string) {
@@ -594,7 +642,7 @@ func ExecuteMACAnalysis(target string) {
 	if utils.IsDBAvailable() {
 		model.AddTarget(utils.Config.DB, target, model.IMPORTED.String())
 	}
-	cmdSweep([]string{"PING", target})
+	runMenuCommand(cmdSweep, "sweep", "PING", target)
 }
 
 func DisplayScanLogs() {
@@ -0,0 +1,217 @@
+package enum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// CONTAINERS / ORCHESTRATION
+// ---------------------------------------------------------------------------------------
+const (
+	CONTAINER_PRODUCT   = "container_product"
+	CONTAINER_VERSION   = "container_version"
+	CONTAINER_ANONYMOUS = "container_anonymous"
+)
+
+// Result of a fingerprint: which API answered, and whether it did without credentials
+type containerResult struct {
+	Product   string
+	Version   string
+	Anonymous bool
+	Evidence  string
+	Severity  string
+}
+
+type containerFingerprint struct {
+	ports []int
+	check func(s *EnumScan, t *bytes.Buffer, base string) (containerResult, bool)
+}
+
+// Tried in order, the ones matching the port first
+var containerFingerprints = []containerFingerprint{
+	{[]int{2375, 2376}, checkDocker},
+	{[]int{6443}, checkKubernetes},
+	{[]int{10250, 10255}, checkKubelet},
+	{[]int{2379}, checkEtcd},
+	{[]int{5000}, checkRegistry},
+}
+
+// Decode a JSON object from a response (nil if the body is not a JSON object)
+func jsonObject(resp *probeResponse) map[string]interface{} {
+	obj := map[string]interface{}{}
+	if resp == nil || json.Unmarshal(resp.Body, &obj) != nil {
+		return nil
+	}
+	return obj
+}
+
+func jsonString(obj map[string]interface{}, key string) string {
+	if v, ok := obj[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Docker Engine API
+func checkDocker(s *EnumScan, t *bytes.Buffer, base string) (containerResult, bool) {
+	resp, _ := s.httpRequest(t, "GET", base+"/version", nil)
+	obj := jsonObject(resp)
+	if resp == nil || resp.Status != 200 || jsonString(obj, "ApiVersion") == "" {
+		return containerResult{}, false
+	}
+	res := containerResult{Product: "Docker", Version: jsonString(obj, "Version"), Severity: model.SEVERITY_CRITICAL}
+	if resp, _ := s.httpRequest(t, "GET", base+"/containers/json", nil); resp != nil && resp.Status == 200 {
+		res.Anonymous, res.Evidence = true, "GET /containers/json"
+	}
+	return res, true
+}
+
+// Kubernetes API server
+func checkKubernetes(s *EnumScan, t *bytes.Buffer, base string) (containerResult, bool) {
+	resp, _ := s.httpRequest(t, "GET", base+"/version", nil)
+	obj := jsonObject(resp)
+	if resp == nil || jsonString(obj, "gitVersion") == "" {
+		// The version can be restricted too: recognise the API by its Status object
+		if obj == nil || jsonString(obj, "kind") != "Status" || jsonString(obj, "apiVersion") != "v1" {
+			return containerResult{}, false
+		}
+	}
+	res := containerResult{Product: "Kubernetes API", Version: jsonString(obj, "gitVersion"), Severity: model.SEVERITY_CRITICAL}
+	if resp, _ := s.httpRequest(t, "GET", base+"/api/v1/namespaces", nil); resp != nil && resp.Status == 200 {
+		res.Anonymous, res.Evidence = true, "GET /api/v1/namespaces"
+	}
+	return res, true
+}
+
+var kubeletVersion = regexp.MustCompile(`kubernetes_build_info\{[^}]*git_version="([^"]+)"`)
+
+// Kubelet API (read-write on 10250, read-only on 10255)
+func checkKubelet(s *EnumScan, t *bytes.Buffer, base string) (containerResult, bool) {
+	resp, _ := s.httpRequest(t, "GET", base+"/pods", nil)
+	if resp == nil {
+		return containerResult{}, false
+	}
+	obj := jsonObject(resp)
+	res := containerResult{Product: "Kubelet", Severity: model.SEVERITY_CRITICAL}
+	if base[:5] == "http:" {
+		res.Product, res.Severity = "Kubelet (read-only)", model.SEVERITY_HIGH
+	}
+	switch {
+	case resp.Status == 200 && jsonString(obj, "kind") == "PodList":
+		res.Anonymous, res.Evidence = true, "GET /pods"
+	case resp.Status == 401 && bytes.Contains(resp.Body, []byte("Unauthorized")),
+		resp.Status == 403 && bytes.Contains(resp.Body, []byte("Forbidden")):
+		// Authentication required (401), or anonymous authentication enabled but denied
+		// by RBAC (403). Any API can deny access: only a kubelet port, or the kubelet health
+		// check, tells that this is one
+		if !kubeletPort(base) {
+			resp, _ := s.httpRequest(t, "GET", base+"/healthz", nil)
+			if resp == nil || resp.Status != 200 || string(bytes.TrimSpace(resp.Body)) != "ok" {
+				return containerResult{}, false
+			}
+		}
+	default:
+		return containerResult{}, false
+	}
+	if resp, _ := s.httpRequest(t, "GET", base+"/metrics", nil); resp != nil && resp.Status == 200 {
+		if m := kubeletVersion.FindSubmatch(resp.Body); m != nil {
+			res.Version = string(m[1])
+		}
+	}
+	return res, true
+}
+
+// Whether the URL is on one of the ports of the kubelet
+func kubeletPort(base string) bool {
+	u, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return port == 10250 || port == 10255
+}
+
+// etcd (v3 gRPC gateway, or v2 keys API)
+func checkEtcd(s *EnumScan, t *bytes.Buffer, base string) (containerResult, bool) {
+	resp, _ := s.httpRequest(t, "GET", base+"/version", nil)
+	obj := jsonObject(resp)
+	if resp == nil || resp.Status != 200 || jsonString(obj, "etcdserver") == "" {
+		return containerResult{}, false
+	}
+	res := containerResult{Product: "etcd", Version: jsonString(obj, "etcdserver"), Severity: model.SEVERITY_CRITICAL}
+	// Count the keys (from "\x00"): fails with 401 when authentication is enabled
+	if resp, _ := s.httpRequest(t, "POST", base+"/v3/kv/range", []byte(`{"key":"AA==","range_end":"AA==","count_only":true}`)); resp != nil && resp.Status == 200 {
+		res.Anonymous, res.Evidence = true, "POST /v3/kv/range"
+	} else if resp, _ := s.httpRequest(t, "GET", base+"/v2/keys", nil); resp != nil && resp.Status == 200 {
+		res.Anonymous, res.Evidence = true, "GET /v2/keys"
+	}
+	return res, true
+}
+
+// Docker registry (distribution API v2)
+func checkRegistry(s *EnumScan, t *bytes.Buffer, base string) (containerResult, bool) {
+	resp, _ := s.httpRequest(t, "GET", base+"/v2/", nil)
+	if resp == nil || resp.Header.Get("Docker-Distribution-Api-Version") == "" {
+		return containerResult{}, false
+	}
+	res := containerResult{Product: "Container registry", Version: resp.Header.Get("Docker-Distribution-Api-Version"), Severity: model.SEVERITY_HIGH}
+	if resp.Status == 200 {
+		res.Anonymous, res.Evidence = true, "GET /v2/"
+		if resp, _ := s.httpRequest(t, "GET", base+"/v2/_catalog", nil); resp != nil && resp.Status == 200 {
+			res.Evidence = "GET /v2/_catalog"
+		}
+	}
+	return res, true
+}
+
+// Fingerprint Docker, Kubernetes, kubelet, etcd and registry APIs with unauthenticated requests
+func probeContainer(s *EnumScan, data stepData) (string, error) {
+	var transcript bytes.Buffer
+	base := fmt.Sprintf("%s://%s", detectScheme(data.Address, data.Port), net.JoinHostPort(data.Address, strconv.Itoa(data.Port)))
+
+	ordered := []containerFingerprint{}
+	others := []containerFingerprint{}
+	for _, fp := range containerFingerprints {
+		hinted := false
+		for _, p := range fp.ports {
+			hinted = hinted || p == data.Port
+		}
+		if hinted {
+			ordered = append(ordered, fp)
+		} else {
+			others = append(others, fp)
+		}
+	}
+
+	for _, fp := range append(ordered, others...) {
+		res, ok := fp.check(s, &transcript, base)
+		if !ok {
+			continue
+		}
+		anonymous := "no"
+		if res.Anonymous {
+			anonymous = "yes"
+		}
+		model.AddDetail(utils.Config.DB, s.Target, data.Port, "CONTAINER", CONTAINER_PRODUCT, res.Product)
+		if res.Version != "" {
+			model.AddDetail(utils.Config.DB, s.Target, data.Port, "CONTAINER", CONTAINER_VERSION, res.Version)
+		}
+		model.AddDetail(utils.Config.DB, s.Target, data.Port, "CONTAINER", CONTAINER_ANONYMOUS, anonymous)
+		s.log().LogNotify(fmt.Sprintf("[CONTAINER] %s %s on %s (anonymous access: %s)", res.Product, res.Version, base, anonymous))
+		if res.Anonymous {
+			model.AddFinding(utils.Config.DB, s.Target, data.Port, "CONTAINER", res.Severity,
+				fmt.Sprintf("Unauthenticated %s", res.Product), fmt.Sprintf("%s (%s)", base, res.Evidence))
+		}
+		return transcript.String(), nil
+	}
+	return transcript.String(), nil
+}
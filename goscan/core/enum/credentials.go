@@ -0,0 +1,81 @@
+package enum
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// CREDENTIALS
+// ---------------------------------------------------------------------------------------
+var (
+	hydraLogin    = regexp.MustCompile(`^\[(\d+)\]\[([^\]]+)\] host: (\S+)\s+login: (.*?)\s+password: (.*)$`)
+	nseValid      = regexp.MustCompile(`^\s*(.*?):(.*) - Valid credentials`)
+	nseEmptyLogin = regexp.MustCompile(`^\s*(.*?):<empty> => Login Success`)
+	nseEmptyAcct  = regexp.MustCompile(`^\s*(\S+) account has empty password`)
+)
+
+// Store a credential found for the target, with its secret encrypted
+// (without it, if the vault is locked: plaintext secrets never reach the DB)
+func (s *EnumScan) addCredential(port int, service, username, secret, source string, verified bool) {
+	enc, err := utils.Encrypt(secret)
+	if err != nil {
+		s.log().LogWarning(fmt.Sprintf("[CREDS] Secret of %s@%s:%d not stored: %s", username, s.Target.Address, port, err))
+		enc = ""
+	}
+	model.AddCredential(utils.Config.DB, s.Target, port, service, username, enc, source, verified)
+	s.log().LogNotify(fmt.Sprintf("[CREDS] %s:%d (%s): valid credentials for %s (%s)", s.Target.Address, port, service, username, source))
+}
+
+// Parse hydra output (-o): one line per valid login
+func parseHydra(s *EnumScan, data stepData, output string) {
+	for _, line := range readLines(output) {
+		m := hydraLogin.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		port, err := strconv.Atoi(m[1])
+		if err != nil {
+			port = data.Port
+		}
+		s.addCredential(port, m[2], m[4], m[5], "hydra", true)
+	}
+}
+
+// Extract credentials from the output of the *-brute and *-empty-password NSE scripts
+// (run on the output of every nmap step)
+func parseNSECredentials(s *EnumScan, data stepData, output string) {
+	for _, sc := range nmapScripts(output) {
+		var service string
+		switch {
+		case strings.HasSuffix(sc.ID, "-brute"):
+			service = strings.TrimSuffix(sc.ID, "-brute")
+		case strings.HasSuffix(sc.ID, "-empty-password"):
+			service = strings.TrimSuffix(sc.ID, "-empty-password")
+		default:
+			continue
+		}
+		port := sc.Port
+		if port == 0 {
+			port = data.Port
+		}
+		for _, line := range strings.Split(sc.Output, "\n") {
+			if m := nseValid.FindStringSubmatch(line); m != nil {
+				secret := m[2]
+				if secret == "<empty>" {
+					secret = ""
+				}
+				s.addCredential(port, service, m[1], secret, sc.ID, true)
+			} else if m := nseEmptyLogin.FindStringSubmatch(line); m != nil {
+				s.addCredential(port, service, m[1], "", sc.ID, true)
+			} else if m := nseEmptyAcct.FindStringSubmatch(line); m != nil {
+				s.addCredential(port, service, m[1], "", sc.ID, true)
+			}
+		}
+	}
+}
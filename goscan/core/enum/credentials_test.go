@@ -0,0 +1,83 @@
+package enum
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// Credentials of the host, as "port/service/username:secret (source)"
+func testCredentials(t *testing.T, s *EnumScan) map[string]bool {
+	t.Helper()
+	res := map[string]bool{}
+	for _, c := range s.Target.GetCredentials(utils.Config.DB) {
+		secret, err := utils.Decrypt(c.Secret)
+		if err != nil {
+			t.Fatalf("%s: cannot decrypt the secret: %s", c.Username, err)
+		}
+		if !c.Verified {
+			t.Errorf("%s: not verified", c.Username)
+		}
+		res[fmt.Sprintf("%d/%s/%s:%s (%s)", c.Port, c.Service, c.Username, secret, c.Source)] = true
+	}
+	return res
+}
+
+var hydraSample = "# Hydra v9.4 run at 2024-03-01 10:00:00 on 10.0.0.12 ssh (hydra -L users.txt -P passwords.txt -o hydra_22 ssh://10.0.0.12)\n" +
+	"[22][ssh] host: 10.0.0.12   login: root   password: toor\n" +
+	"[22][ssh] host: 10.0.0.12   login: deploy   password: Summer 2024!\r\n" +
+	"[2222][ssh] host: 10.0.0.12   login: backup   password: backup\n" +
+	"1 of 1 target successfully completed, 3 valid passwords found\n"
+
+func TestParseHydra(t *testing.T) {
+	s := testScan(t, "10.0.0.12")
+	parseHydra(s, stepData{Address: "10.0.0.12", Port: 22}, testOutput(t, "hydra_22", hydraSample))
+
+	want := map[string]bool{
+		"22/ssh/root:toor (hydra)":           true,
+		"22/ssh/deploy:Summer 2024! (hydra)": true,
+		"2222/ssh/backup:backup (hydra)":     true,
+	}
+	if creds := testCredentials(t, s); !reflect.DeepEqual(creds, want) {
+		t.Errorf("credentials:\ngot  %v\nwant %v", creds, want)
+	}
+}
+
+// nmap XML with the output of brute-force and empty-password scripts
+var nseCredentialsSample = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap -p21,1433,3306 --script=ftp-brute,ftp-anon,ms-sql-empty-password,mysql-empty-password 10.0.0.13" start="1709287200" version="7.94" xmloutputversion="1.05">
+<host starttime="1709287200" endtime="1709287801"><status state="up" reason="syn-ack" reason_ttl="0"/>
+<address addr="10.0.0.13" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="21"><state state="open" reason="syn-ack" reason_ttl="0"/><service name="ftp" method="table" conf="3"/>
+<script id="ftp-anon" output="Anonymous FTP login allowed (FTP code 230)"/>
+<script id="ftp-brute" output="&#xa;  Accounts: &#xa;    ftpuser:ftpuser - Valid credentials&#xa;    anonymous:&lt;empty&gt; - Valid credentials&#xa;  Statistics: Performed 3422 guesses in 601 seconds, average tps: 5.7"/>
+</port>
+<port protocol="tcp" portid="1433"><state state="open" reason="syn-ack" reason_ttl="0"/><service name="ms-sql-s" method="table" conf="3"/>
+<script id="ms-sql-empty-password" output="&#xa;  [10.0.0.13:1433]&#xa;    sa:&lt;empty&gt; =&gt; Login Success"/>
+</port>
+<port protocol="tcp" portid="3306"><state state="open" reason="syn-ack" reason_ttl="0"/><service name="mysql" method="table" conf="3"/>
+<script id="mysql-empty-password" output="&#xa;  root account has empty password&#xa;"/>
+</port>
+</ports>
+</host>
+<runstats><finished time="1709287801" timestr="Fri Mar  1 10:10:01 2024" elapsed="601.00" summary="" exit="success"/><hosts up="1" down="0" total="1"/></runstats>
+</nmaprun>
+`
+
+func TestParseNSECredentials(t *testing.T) {
+	s := testScan(t, "10.0.0.13")
+	parseNSECredentials(s, stepData{Address: "10.0.0.13", Port: 21}, testOutput(t, "nse_credentials.xml", nseCredentialsSample))
+
+	want := map[string]bool{
+		"21/ftp/ftpuser:ftpuser (ftp-brute)":      true,
+		"21/ftp/anonymous: (ftp-brute)":           true,
+		"1433/ms-sql/sa: (ms-sql-empty-password)": true,
+		"3306/mysql/root: (mysql-empty-password)": true,
+	}
+	if creds := testCredentials(t, s); !reflect.DeepEqual(creds, want) {
+		t.Errorf("credentials:\ngot  %v\nwant %v", creds, want)
+	}
+}
@@ -0,0 +1,110 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// DATABASES (UNAUTHENTICATED ACCESS)
+// ---------------------------------------------------------------------------------------
+const (
+	DB_AUTH_REQUIRED = "auth_required"
+)
+
+// How to tell, from the output of a step, whether a database answered without credentials
+type unauthCheck struct {
+	service string
+	scripts []string // NSE scripts to look at (nmap steps)
+	success []string // data was returned
+	denied  []string // authentication is required
+}
+
+var unauthChecks = map[string]unauthCheck{
+	"postgresql": {
+		service: "PostgreSQL",
+		success: []string{"PostgreSQL "},
+		denied:  []string{"authentication failed", "no password supplied", "no pg_hba.conf entry"},
+	},
+	"mongodb": {
+		service: "MongoDB",
+		scripts: []string{"mongodb-databases"},
+		success: []string{"totalSize", "databases"},
+		denied:  []string{"unauthorized", "requires authentication", "not authorized"},
+	},
+	"redis": {
+		service: "Redis",
+		scripts: []string{"redis-info"},
+		success: []string{"Version", "Operating System"},
+		denied:  []string{"NOAUTH", "Authentication required"},
+	},
+	"elasticsearch": {
+		service: "Elasticsearch",
+		success: []string{"\"cluster_name\""},
+		denied:  []string{"security_exception", "missing authentication"},
+	},
+	"couchdb": {
+		service: "CouchDB",
+		scripts: []string{"couchdb-databases"},
+		success: []string{"_users", "_replicator", "Databases"},
+		denied:  []string{"unauthorized", "not a server admin"},
+	},
+	"cassandra": {
+		service: "Cassandra",
+		success: []string{"system_schema", "system_auth"},
+		denied:  []string{"AuthenticationFailed", "requires authentication"},
+	},
+	"memcached": {
+		service: "Memcached",
+		scripts: []string{"memcached-info"},
+		success: []string{"Process ID", "Uptime"},
+		denied:  []string{"authentication required", "SASL"},
+	},
+}
+
+func containsAny(text string, markers []string) string {
+	lower := strings.ToLower(text)
+	for _, m := range markers {
+		if strings.Contains(lower, strings.ToLower(m)) {
+			return m
+		}
+	}
+	return ""
+}
+
+// Returns a parser flagging "no auth required" (high severity) for the given database
+func unauthParser(name string) resultParser {
+	return func(s *EnumScan, data stepData, output string) {
+		check := unauthChecks[name]
+
+		text := ""
+		if strings.HasSuffix(output, ".xml") {
+			for _, sc := range nmapScripts(output) {
+				for _, id := range check.scripts {
+					if sc.ID == id {
+						text = fmt.Sprintf("%s\n%s", text, sc.Output)
+					}
+				}
+			}
+		} else {
+			text = strings.Join(readLines(output), "\n")
+		}
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+
+		if m := containsAny(text, check.denied); m != "" {
+			model.AddDetail(utils.Config.DB, s.Target, data.Port, check.service, DB_AUTH_REQUIRED, "yes")
+			return
+		}
+		if m := containsAny(text, check.success); m != "" {
+			model.AddDetail(utils.Config.DB, s.Target, data.Port, check.service, DB_AUTH_REQUIRED, "no")
+			model.AddFinding(utils.Config.DB, s.Target, data.Port, check.service, model.SEVERITY_HIGH,
+				"No authentication required", fmt.Sprintf("%s accepts unauthenticated access (%s)", check.service, strings.TrimSpace(m)))
+			s.log().LogNotify(fmt.Sprintf("[%s] No authentication required on %s:%d", check.service, s.Target.Address, data.Port))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package enum
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"time"
 
@@ -22,10 +23,12 @@ var EnumList = []*EnumScan{}
 func NewEnumScan(target *model.Host, kind, polite string) *EnumScan {
 	// Create a Scan
 	s := &EnumScan{
+		ID:     model.NextJobID(),
 		Target: target,
 		Kind:   kind,
 		Polite: polite,
 		Status: model.NOT_STARTED,
+		Origin: utils.CurrentOrigin(),
 	}
 	return s
 }
@@ -34,7 +37,10 @@ func (s *EnumScan) preScan() {
 	s.Status = model.IN_PROGRESS
 }
 func (s *EnumScan) postScan() {
-	s.Status = model.FINISHED
+	// A cancelled (or still waiting) job keeps its status, failed steps don't fail the job
+	if s.Status != model.CANCELLED && s.Status != model.WAITING {
+		s.Status = model.FINISHED
+	}
 }
 
 func (s *EnumScan) makeOutputPath(folder, file string) string {
@@ -44,71 +50,118 @@ func (s *EnumScan) makeOutputPath(folder, file string) string {
 	return resFile
 }
 
+// Context used to audit the commands run by the enumeration
+func (s *EnumScan) job() utils.Job {
+	return utils.Job{ID: s.ID, Kind: s.Kind, Target: s.Target.Address, Origin: s.Origin}
+}
+
+// Logger adding the fields of the enumeration to every record
+func (s *EnumScan) log() *utils.Logger {
+	return utils.Config.Log.WithJob(s.job())
+}
+
 func (s *EnumScan) runCmd(cmd string) (string, error) {
 	// If it's a dry run, only show the command
 	if s.Polite == "DRY" {
-		utils.Config.Log.LogDebug(fmt.Sprintf("[DRY RUN] %s", cmd))
+		s.log().LogInfo(fmt.Sprintf("[DRY RUN] %s", cmd))
 		return "", nil
 	}
+	// Skip remaining steps if the job has been cancelled, and respect the scanning windows
+	if s.Status == model.CANCELLED {
+		return "", utils.ErrCancelled
+	}
+	scan.WaitForWindow(&s.Status, &s.Reason)
 	// Otherwise execute the command
-	res, err := utils.ShellCmd(cmd)
-	if err != nil {
-		s.Status = model.FAILED
+	res, err := utils.ShellCmdJob(s.job(), cmd)
+	if err == utils.ErrCancelled {
+		s.Status = model.CANCELLED
+		s.Reason = "scanning window closed"
+	} else if err != nil {
+		// The other steps of the enumeration still run
+		s.log().LogWarning(fmt.Sprintf("Step failed: %s (%s)", cmd, err))
 	}
 	return res, err
 }
 
+func (s *EnumScan) runNative(name string, data stepData) {
+	// If it's a dry run, only show the probe
+	if s.Polite == "DRY" {
+		s.log().LogInfo(fmt.Sprintf("[DRY RUN] native %s probe against %s:%d", name, data.Address, data.Port))
+		return
+	}
+	// Skip remaining steps if the job has been cancelled, and respect the scanning windows
+	if s.Status == model.CANCELLED {
+		return
+	}
+	scan.WaitForWindow(&s.Status, &s.Reason)
+	// Otherwise run the probe, and keep its transcript
+	transcript, err := natives[name](s, data)
+	if err != nil {
+		s.log().LogError(fmt.Sprintf("Native %s probe failed on %s:%d: %s", name, data.Address, data.Port, err))
+	}
+	if err := ioutil.WriteFile(data.Output, []byte(transcript), 0644); err != nil {
+		s.log().LogError(fmt.Sprintf("Cannot write output file %s: %s", data.Output, err))
+	}
+}
+
+// Checked by the native probes before every request, as they are not processes that
+// EnforceWindows can pause or kill: waits while the scanning window is closed (or cancels
+// the job if the window says so), and returns ErrCancelled once the job is cancelled
+func (s *EnumScan) probeAllowed() error {
+	if s.Status == model.CANCELLED {
+		return utils.ErrCancelled
+	}
+	if open, _, action := scan.CheckWindow(time.Now()); !open {
+		if action == model.WINDOW_ACTION_CANCEL {
+			s.Status, s.Reason = model.CANCELLED, "scanning window closed"
+			return utils.ErrCancelled
+		}
+		scan.WaitForWindow(&s.Status, &s.Reason)
+	}
+	return nil
+}
+
 func (s *EnumScan) runNmap(name, target, folder, file, nmapArgs string) {
 	// If it's a dry run, only show the command
 	if s.Polite == "DRY" {
 		outfolder := filepath.Join(utils.Config.Outfolder, utils.CleanPath(target), folder)
 		outfile := filepath.Join(outfolder, file)
 		cmd := fmt.Sprintf("nmap %s %s -oA %s", nmapArgs, target, outfile)
-		utils.Config.Log.LogDebug(fmt.Sprintf("To be run: %s", cmd))
+		s.log().LogInfo(fmt.Sprintf("To be run: %s", cmd))
+		return
+	}
+	// Skip remaining steps if the job has been cancelled, and respect the scanning windows
+	if s.Status == model.CANCELLED {
 		return
 	}
+	scan.WaitForWindow(&s.Status, &s.Reason)
 	// Otherwise execute the command
 	nmap := scan.NewScan(name, target, folder, file, nmapArgs)
+	// The nmap scan runs as part of this enumeration job
+	nmap.ID, nmap.Origin = s.ID, s.Origin
 	nmap.RunNmap()
+	if nmap.Status == model.CANCELLED {
+		s.Status = model.CANCELLED
+		s.Reason = nmap.Reason
+	}
 }
 
 func (s *EnumScan) Run() {
 	// Pre-scan checks
 	s.preScan()
 
-	// Dispatch scan
-	switch s.Kind {
-	case "DNS":
-		s.EnumDNS()
-	case "FINGER":
-		s.EnumFINGER()
-	case "FTP":
-		s.EnumFTP()
-	case "HTTP":
-		s.EnumHTTP()
-	case "RDP":
-		s.EnumRDP()
-	case "SMB":
-		s.EnumSMB()
-	case "SMTP":
-		s.EnumSMTP()
-	case "SNMP":
-		s.EnumSNMP()
-	case "SQL":
-		s.EnumSQL()
-	case "SSH":
-		s.EnumSSH()
-	case "ALL":
-		s.EnumDNS()
-		s.EnumFINGER()
-		s.EnumFTP()
-		s.EnumHTTP()
-		s.EnumRDP()
-		s.EnumSMB()
-		s.EnumSMTP()
-		s.EnumSNMP()
-		s.EnumSQL()
-		s.EnumSSH()
+	// Dispatch scan to the matching recipe(s)
+	recipes := GetRecipes()
+	if s.Kind == "ALL" {
+		for _, kind := range RecipeKinds(recipes) {
+			s.runRecipe(recipes[kind])
+		}
+	} else if r, ok := recipes[s.Kind]; ok {
+		s.runRecipe(r)
+	} else {
+		s.log().LogError(fmt.Sprintf("No recipe available for enumeration kind: %s", s.Kind))
+		s.Status = model.FAILED
+		return
 	}
 
 	// Post-scan checks
@@ -119,12 +172,19 @@ func (s *EnumScan) Run() {
 // SCAN LAUNCHER
 // ---------------------------------------------------------------------------------------
 func ScanEnumerate(kind, polite, target string) {
+	kind = resolveKind(GetRecipes(), kind)
+	// Polite-only recipes (e.g., ICS) reject AGGRESSIVE
+	if !CheckRecipes(GetRecipes(), kind, polite) {
+		return
+	}
 	utils.Config.Log.LogInfo("Starting service enumeration")
+	// Jobs are started asynchronously, keep track of the command that originated them
+	origin := utils.CurrentOrigin()
 
 	// If no database is available, run directly against the provided target
 	if !utils.IsDBAvailable() {
 		temp := model.Host{Address: target, Step: model.NEW.String()}
-		go workerEnum(&temp, kind, polite)
+		go workerEnum(&temp, kind, polite, origin)
 		return
 	}
 
@@ -135,14 +195,15 @@ func ScanEnumerate(kind, polite, target string) {
 		//   - or if host is the selected one
 		if target == "ALL" || target == h.Address {
 			temp := h
-			go workerEnum(&temp, kind, polite)
+			go workerEnum(&temp, kind, polite, origin)
 		}
 	}
 }
 
-func workerEnum(h *model.Host, kind string, polite string) {
+func workerEnum(h *model.Host, kind string, polite string, origin string) {
 	// Instantiate new EnumScan
 	s := NewEnumScan(h, kind, polite)
+	s.Origin = origin
 	EnumList = append(EnumList, s)
 
 	// Run the scan
@@ -164,15 +225,21 @@ func ReportStatusEnum() {
 				case scan.Status == model.NULL:
 					break
 				case scan.Status == model.FAILED:
-					utils.Config.Log.LogError(fmt.Sprintf("Enumeration failed on host: %s", scan.Target.Address))
+					scan.log().LogError(fmt.Sprintf("Enumeration failed on host: %s", scan.Target.Address))
+				case scan.Status == model.CANCELLED:
+					scan.log().LogWarning(fmt.Sprintf("[%s] Enumeration cancelled on host:\t%s (%s)", scan.Kind, scan.Target.Address, scan.Reason))
+				case scan.Status == model.WAITING:
+					scan.log().LogInfo(fmt.Sprintf("[%s] Enumeration waiting on host:\t%s (%s)", scan.Kind, scan.Target.Address, scan.Reason))
+					EnumList[i] = scan
+					i++
 				case scan.Status == model.IN_PROGRESS:
-					utils.Config.Log.LogInfo(fmt.Sprintf("[%s] Enumeration in progress on host:\t%s", scan.Kind, scan.Target.Address))
+					scan.log().LogInfo(fmt.Sprintf("[%s] Enumeration in progress on host:\t%s", scan.Kind, scan.Target.Address))
 					// Update in place, remove finished scans
 					EnumList[i] = scan
 					i++
 				case scan.Status == model.FINISHED:
-					utils.Config.Log.LogNotify(fmt.Sprintf("[%s] Enumeration finished on host:\t%s", scan.Kind, scan.Target.Address))
-					utils.Config.Log.LogNotify(fmt.Sprintf("[%s] Output has been saved at:\t%s", scan.Kind, utils.Config.Outfolder))
+					scan.log().LogNotify(fmt.Sprintf("[%s] Enumeration finished on host:\t%s", scan.Kind, scan.Target.Address))
+					scan.log().LogNotify(fmt.Sprintf("[%s] Output has been saved at:\t%s", scan.Kind, utils.Config.Outfolder))
 				}
 			}
 			// Update in place, remove finished scans
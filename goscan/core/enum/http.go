@@ -1,75 +1,193 @@
 package enum
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
 )
 
-func (s *EnumScan) EnumHTTP() {
-	// Skip if database not available (Windows limitation)
-	if !utils.IsDBAvailable() {
-		utils.Config.Log.LogWarning("HTTP enumeration skipped (database unavailable)")
+// ---------------------------------------------------------------------------------------
+// HTTP (nikto, dirb, sqlmap)
+// ---------------------------------------------------------------------------------------
+// Item reported by nikto, whatever its output format
+type niktoItem struct {
+	Method string
+	URI    string
+	Msg    string
+}
+
+// nikto -Format xml: <item> elements, nested in <niktoscan>/<scandetails>
+type niktoXMLItem struct {
+	Method      string `xml:"method,attr"`
+	Description string `xml:"description"`
+	URI         string `xml:"uri"`
+}
+
+// nikto -Format json: one object per host (an array of them in recent versions)
+type niktoJSONHost struct {
+	Vulnerabilities []struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Msg    string `json:"msg"`
+	} `json:"vulnerabilities"`
+}
+
+var niktoText = regexp.MustCompile(`^\+ (?:OSVDB-\d+: )?(/\S*): (.+)$`)
+
+func parseNiktoXML(data []byte) ([]niktoItem, error) {
+	items := []niktoItem{}
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "item" {
+			it := niktoXMLItem{}
+			if err := dec.DecodeElement(&it, &se); err != nil {
+				return items, err
+			}
+			items = append(items, niktoItem{Method: it.Method, URI: strings.TrimSpace(it.URI), Msg: strings.TrimSpace(it.Description)})
+		}
+	}
+	return items, nil
+}
+
+func parseNiktoJSON(data []byte) ([]niktoItem, error) {
+	hosts := []niktoJSONHost{}
+	if err := json.Unmarshal(data, &hosts); err != nil {
+		host := niktoJSONHost{}
+		if err := json.Unmarshal(data, &host); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	items := []niktoItem{}
+	for _, h := range hosts {
+		for _, v := range h.Vulnerabilities {
+			items = append(items, niktoItem{Method: v.Method, URI: v.URL, Msg: v.Msg})
+		}
+	}
+	return items, nil
+}
+
+// Parse nikto output: the XML (<output>.xml) or JSON (<output>.json) report if available,
+// the text output otherwise. Every item becomes a finding
+func parseNikto(s *EnumScan, data stepData, output string) {
+	var items []niktoItem
+	var err error
+	if raw, e := ioutil.ReadFile(output + ".xml"); e == nil {
+		items, err = parseNiktoXML(raw)
+	} else if raw, e := ioutil.ReadFile(output + ".json"); e == nil {
+		items, err = parseNiktoJSON(raw)
+	} else {
+		for _, line := range readLines(output) {
+			if m := niktoText.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+				items = append(items, niktoItem{URI: m[1], Msg: m[2]})
+			}
+		}
+	}
+	if err != nil {
+		s.log().LogWarning(fmt.Sprintf("[HTTP] Cannot parse nikto report of %s:%d: %s", data.Address, data.Port, err))
+	}
+
+	for _, it := range items {
+		if it.Msg == "" {
+			continue
+		}
+		// nikto prefixes the messages with the URI
+		msg := strings.TrimPrefix(it.Msg, it.URI+": ")
+		detail := fmt.Sprintf("%s://%s:%d%s", data.Scheme, data.Address, data.Port, it.URI)
+		if it.Method != "" {
+			detail = fmt.Sprintf("%s %s", it.Method, detail)
+		}
+		model.AddFinding(utils.Config.DB, s.Target, data.Port, "HTTP", model.SEVERITY_LOW, msg, detail)
+	}
+	if len(items) > 0 {
+		s.log().LogNotify(fmt.Sprintf("[HTTP] %s:%d: %d nikto items", data.Address, data.Port, len(items)))
+	}
+}
+
+var (
+	dirbURL       = regexp.MustCompile(`^\+ (\S+) \(CODE:(\d+)\|SIZE:(\d+)\)`)
+	dirbDirectory = regexp.MustCompile(`^==> DIRECTORY: (\S+)`)
+)
+
+// Parse dirb output (-o): discovered URLs and directories
+func parseDirb(s *EnumScan, data stepData, output string) {
+	if data.service == nil {
 		return
 	}
-	for _, port := range s.Target.GetPorts(utils.Config.DB) {
-		// Enumerate only if port is open
-		if port.Status == "open" {
-			// Dispatch the correct scanner
-			service := port.GetService(utils.Config.DB)
-			if port.Number == 80 || port.Number == 443 || port.Number == 8080 ||
-				strings.Contains(strings.ToLower(service.Name), "http") ||
-				strings.Contains(strings.ToLower(service.Name), "https") ||
-				strings.Contains(strings.ToLower(service.Name), "ssl/http") {
-				// Start Enumerating
-				utils.Config.Log.LogInfo(fmt.Sprintf("Starting Enumeration: %s:%d (%s)", s.Target.Address, port.Number, service.Name))
-				protocol := "http"
-				if strings.Contains(strings.ToLower(service.Name), "https") ||
-					strings.Contains(strings.ToLower(service.Name), "ssl/http") {
-					protocol = "https"
-				}
-
-				// -----------------------------------------------------------------------
-				// NMAP
-				// -----------------------------------------------------------------------
-				name := fmt.Sprintf("%s_http_%d_nmap", s.Target.Address, port.Number)
-				nmapArgs := fmt.Sprintf("-sV -Pn --script=http-vhosts,http-userdir-enum,http-apache-negotiation,http-backup-finder,http-config-backup,http-default-accounts,http-methods,http-method-tamper,http-passwd,http-sitemap-generator,http-auth-finder,http-auth,http-fileupload-exploiter,http-put,http-sql-injection,http-stored-xss,http-xssed,http-php-version,http-unsafe-output-escaping,http-phpmyadmin-dir-traversal,http-ntlm-info,http-phpself-xss,http-open-redirect,http-iis-webdav-vuln,http-form-fuzzer,http-vuln-cve2009-3960,http-vuln-cve2010-0738,http-vuln-cve2010-2861,http-vuln-cve2011-3368,http-vuln-cve2012-1823,http-vuln-cve2013-0156,http-robots.txt,http-wordpress-brute,http-wordpress-enum --script-args http.useragent='Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:15.0) Gecko/20100101 Firefox/15.0.1',http-put.url='/uploads/rootme.php',http-put.file='/root/www/php-reverse.php',basepath='/' -p%d", port.Number)
-				s.runNmap(name, s.Target.Address, "HTTP", name, nmapArgs)
-
-				// -----------------------------------------------------------------------
-				// NIKTO
-				// -----------------------------------------------------------------------
-				output := s.makeOutputPath("HTTP", fmt.Sprintf("%s_http_%d_nikto", s.Target.Address, port.Number))
-				cmd := fmt.Sprintf("nikto -host %s -p %d > %s", s.Target.Address, port.Number, output)
-				s.runCmd(cmd)
-
-				// -----------------------------------------------------------------------
-				// DIRB
-				// -----------------------------------------------------------------------
-				output = s.makeOutputPath("HTTP", fmt.Sprintf("%s_http_%d_dirb", s.Target.Address, port.Number))
-				cmd = fmt.Sprintf("dirb %s://%s:%d -o %s -S -r", protocol, s.Target.Address, port.Number, output)
-				s.runCmd(cmd)
-
-				// -----------------------------------------------------------------------
-				// SQLMAP - NON POLITE
-				// -----------------------------------------------------------------------
-				if s.Polite != "POLITE" {
-					output := s.makeOutputPath("HTTP", fmt.Sprintf("%s_http_%d_sqlmap", s.Target.Address, port.Number))
-					cmd := fmt.Sprintf("sqlmap -u %s://%s:%d --crawl=1 > %s", protocol, s.Target.Address, port.Number, output)
-					s.runCmd(cmd)
-				}
-
-				// -----------------------------------------------------------------------
-				// FIMAP - NON POLITE
-				// -----------------------------------------------------------------------
-				if s.Polite != "POLITE" {
-					output = s.makeOutputPath("HTTP", fmt.Sprintf("%s_http_%d_fimap", s.Target.Address, port.Number))
-					cmd = fmt.Sprintf("fimap -u \"%s://%s:%d\" > %s", protocol, s.Target.Address, port.Number, output)
-					s.runCmd(cmd)
-				}
+	db := utils.Config.DB
+	count := 0
+	for _, line := range readLines(output) {
+		line = strings.TrimRight(line, "\r")
+		if m := dirbURL.FindStringSubmatch(line); m != nil {
+			code, _ := strconv.Atoi(m[2])
+			size, _ := strconv.Atoi(m[3])
+			model.AddWebURL(db, data.service, m[1], code, size, "dirb")
+			count++
+		} else if m := dirbDirectory.FindStringSubmatch(line); m != nil {
+			model.AddWebURL(db, data.service, m[1], 0, 0, "dirb")
+			count++
+		}
+	}
+	if count > 0 {
+		s.log().LogNotify(fmt.Sprintf("[HTTP] %s:%d: %d URLs discovered by dirb", data.Address, data.Port, count))
+	}
+}
+
+var (
+	sqlmapTarget    = regexp.MustCompile(`testing URL '([^']+)'`)
+	sqlmapParameter = regexp.MustCompile(`^Parameter: (.+) \((\S+)\)$`)
+	sqlmapType      = regexp.MustCompile(`^\s+Type: (.+)$`)
+)
 
+// Parse sqlmap output: crawled URLs, and the injectable parameters (with the techniques
+// found) as findings
+func parseSqlmap(s *EnumScan, data stepData, output string) {
+	db := utils.Config.DB
+	target := fmt.Sprintf("%s://%s:%d", data.Scheme, data.Address, data.Port)
+	param := ""
+	injections := map[string][]string{}
+	order := []string{}
+
+	for _, line := range readLines(output) {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case sqlmapTarget.MatchString(line):
+			target = sqlmapTarget.FindStringSubmatch(line)[1]
+			if data.service != nil {
+				model.AddWebURL(db, data.service, target, 0, 0, "sqlmap")
+			}
+			param = ""
+		case sqlmapParameter.MatchString(line):
+			m := sqlmapParameter.FindStringSubmatch(line)
+			param = fmt.Sprintf("%s parameter %s (%s)", target, m[1], m[2])
+			if _, ok := injections[param]; !ok {
+				injections[param] = []string{}
+				order = append(order, param)
 			}
+		case param != "" && sqlmapType.MatchString(line):
+			injections[param] = append(injections[param], sqlmapType.FindStringSubmatch(line)[1])
 		}
 	}
+
+	for _, p := range order {
+		techniques := injections[p]
+		sort.Strings(techniques)
+		model.AddFinding(db, s.Target, data.Port, "HTTP", model.SEVERITY_HIGH,
+			"SQL injection", fmt.Sprintf("%s: %s", p, strings.Join(techniques, ", ")))
+	}
+	if len(order) > 0 {
+		s.log().LogNotify(fmt.Sprintf("[HTTP] %s:%d: %d injectable parameters found by sqlmap", data.Address, data.Port, len(order)))
+	}
 }
@@ -0,0 +1,213 @@
+package enum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+var niktoTextSample = `- Nikto v2.1.6
+---------------------------------------------------------------------------
++ Target IP:          10.0.0.7
++ Target Hostname:    10.0.0.7
++ Target Port:        80
++ Start Time:         2024-03-01 10:00:00 (GMT0)
+---------------------------------------------------------------------------
++ Server: Apache/2.4.29 (Ubuntu)
++ The anti-clickjacking X-Frame-Options header is not present.
++ OSVDB-3233: /icons/README: Apache default file found.
++ /phpinfo.php: Output from the phpinfo() function was found.
++ OSVDB-3092: /admin/: This might be interesting...
++ 7915 requests: 0 error(s) and 6 item(s) reported on remote host
++ End Time:           2024-03-01 10:04:12 (GMT0) (252 seconds)
+---------------------------------------------------------------------------
++ 1 host(s) tested
+`
+
+var niktoXMLSample = `<?xml version="1.0" ?>
+<!DOCTYPE niktoscan SYSTEM "/usr/share/doc/nikto/nikto.dtd">
+<niktoscan hoststest="0" options="-h 10.0.0.7 -p 443 -Format xml" version="2.1.6" nxmlversion="1.2">
+<scandetails targetip="10.0.0.7" targethostname="10.0.0.7" targetport="443" targetbanner="nginx" sitename="https://10.0.0.7:443/" siteip="https://10.0.0.7:443/" hostheader="10.0.0.7" errors="0" checks="6544">
+<item id="999986" osvdbid="0" osvdblink="" method="GET">
+<description><![CDATA[/: The site uses SSL and the Strict-Transport-Security HTTP header is not defined.]]></description>
+<uri><![CDATA[/]]></uri>
+<namelink><![CDATA[https://10.0.0.7:443/]]></namelink>
+<iplink><![CDATA[https://10.0.0.7:443/]]></iplink>
+</item>
+<item id="3092" osvdbid="3092" osvdblink="http://osvdb.org/3092" method="GET">
+<description><![CDATA[/backup/: This might be interesting...]]></description>
+<uri><![CDATA[/backup/]]></uri>
+<namelink><![CDATA[https://10.0.0.7:443/backup/]]></namelink>
+<iplink><![CDATA[https://10.0.0.7:443/backup/]]></iplink>
+</item>
+<statistics elapsed="12" itemsfound="2" itemstested="6544" />
+</scandetails>
+</niktoscan>
+`
+
+var niktoJSONSample = `[{"host":"10.0.0.7","ip":"10.0.0.7","port":"8080","banner":"","vulnerabilities":[` +
+	`{"id":"999100","references":"","method":"GET","url":"/","msg":"Uncommon header 'x-powered-by' found, with contents: Express."},` +
+	`{"id":"999957","references":"","method":"OPTIONS","url":"/","msg":"Allowed HTTP Methods: GET, HEAD, PUT, DELETE, OPTIONS ."}]}]`
+
+func TestParseNikto(t *testing.T) {
+	cases := []struct {
+		name     string
+		address  string
+		data     stepData
+		files    map[string]string
+		findings map[string]bool
+	}{
+		{
+			"text", "10.0.0.7",
+			stepData{Address: "10.0.0.7", Port: 80, Scheme: "http"},
+			map[string]string{"": niktoTextSample},
+			map[string]bool{
+				"Apache default file found.: http://10.0.0.7:80/icons/README":                   true,
+				"Output from the phpinfo() function was found.: http://10.0.0.7:80/phpinfo.php": true,
+				"This might be interesting...: http://10.0.0.7:80/admin/":                       true,
+			},
+		},
+		{
+			"xml", "10.0.0.8",
+			stepData{Address: "10.0.0.8", Port: 443, Scheme: "https"},
+			// The report wins over the text output
+			map[string]string{"": niktoTextSample, ".xml": niktoXMLSample},
+			map[string]bool{
+				"The site uses SSL and the Strict-Transport-Security HTTP header is not defined.: GET https://10.0.0.8:443/": true,
+				"This might be interesting...: GET https://10.0.0.8:443/backup/":                                             true,
+			},
+		},
+		{
+			"json", "10.0.0.9",
+			stepData{Address: "10.0.0.9", Port: 8080, Scheme: "http"},
+			map[string]string{".json": niktoJSONSample},
+			map[string]bool{
+				"Uncommon header 'x-powered-by' found, with contents: Express.: GET http://10.0.0.9:8080/": true,
+				"Allowed HTTP Methods: GET, HEAD, PUT, DELETE, OPTIONS .: OPTIONS http://10.0.0.9:8080/":   true,
+			},
+		},
+	}
+	for _, c := range cases {
+		s := testScan(t, c.address)
+		for ext, content := range c.files {
+			testOutput(t, "nikto_"+c.name+ext, content)
+		}
+		parseNikto(s, c.data, filepath.Join(utils.Config.Outfolder, "nikto_"+c.name))
+		if findings := testFindings(s); !reflect.DeepEqual(findings, c.findings) {
+			t.Errorf("%s: findings:\ngot  %v\nwant %v", c.name, findings, c.findings)
+		}
+	}
+}
+
+var dirbSample = `
+-----------------
+DIRB v2.22
+By The Dark Raver
+-----------------
+
+OUTPUT_FILE: dirb_80
+URL_BASE: http://10.0.0.10:80/
+WORDLIST_FILES: /usr/share/dirb/wordlists/common.txt
+
+-----------------
+
+GENERATED WORDS: 4612
+
+---- Scanning URL: http://10.0.0.10:80/ ----
+==> DIRECTORY: http://10.0.0.10:80/admin/
++ http://10.0.0.10:80/index.php (CODE:200|SIZE:11321)
++ http://10.0.0.10:80/server-status (CODE:403|SIZE:277)
+
+---- Entering directory: http://10.0.0.10:80/admin/ ----
++ http://10.0.0.10:80/admin/index.php (CODE:302|SIZE:0)
+
+-----------------
+DOWNLOADED: 9224 - FOUND: 3
+`
+
+func TestParseDirb(t *testing.T) {
+	s := testScan(t, "10.0.0.10")
+	srv := testService(t, s, 80, "http")
+	parseDirb(s, stepData{Address: "10.0.0.10", Port: 80, Scheme: "http", service: srv}, testOutput(t, "dirb_80", dirbSample))
+
+	urls := map[string][2]int{}
+	for _, u := range srv.GetWebURLs(utils.Config.DB) {
+		if u.Source != "dirb" {
+			t.Errorf("%s: got source %s, want dirb", u.URL, u.Source)
+		}
+		urls[u.URL] = [2]int{u.StatusCode, u.Size}
+	}
+	want := map[string][2]int{
+		"http://10.0.0.10:80/admin/":          {0, 0},
+		"http://10.0.0.10:80/index.php":       {200, 11321},
+		"http://10.0.0.10:80/server-status":   {403, 277},
+		"http://10.0.0.10:80/admin/index.php": {302, 0},
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("urls:\ngot  %v\nwant %v", urls, want)
+	}
+}
+
+var sqlmapSample = `        ___
+       __H__
+ ___ ___[)]_____ ___ ___  {1.7.2#stable}
+|_ -| . [)]     | .'| . |
+|___|_  [']_|_|_|__,|  _|
+      |_|V...       |_|   https://sqlmap.org
+
+[10:00:00] [INFO] starting crawler for target URL 'http://10.0.0.11:80/'
+[10:00:03] [INFO] found a total of 2 targets
+[10:00:03] [INFO] testing URL 'http://10.0.0.11:80/item.php?id=1'
+[10:00:04] [INFO] testing connection to the target URL
+[10:00:09] [INFO] GET parameter 'id' appears to be 'AND boolean-based blind - WHERE or HAVING clause' injectable
+sqlmap identified the following injection point(s) with a total of 46 HTTP(s) requests:
+---
+Parameter: id (GET)
+    Type: time-based blind
+    Title: MySQL >= 5.0.12 AND time-based blind (query SLEEP)
+    Payload: id=1 AND (SELECT 9440 FROM (SELECT(SLEEP(5)))xYpt)
+
+    Type: boolean-based blind
+    Title: AND boolean-based blind - WHERE or HAVING clause
+    Payload: id=1 AND 5050=5050
+---
+[10:00:12] [INFO] testing URL 'http://10.0.0.11:80/search.php'
+[10:00:15] [WARNING] POST parameter 'q' does not seem to be injectable
+[10:00:16] [INFO] testing URL 'http://10.0.0.11:80/login.php'
+sqlmap identified the following injection point(s) with a total of 12 HTTP(s) requests:
+---
+Parameter: user (POST)
+    Type: UNION query
+    Title: Generic UNION query (NULL) - 3 columns
+    Payload: user=-1 UNION ALL SELECT NULL,NULL,CONCAT(0x71)-- -&pass=x
+---
+[10:00:20] [INFO] you can find results of scanning in multiple targets mode inside the CSV file
+`
+
+func TestParseSqlmap(t *testing.T) {
+	s := testScan(t, "10.0.0.11")
+	srv := testService(t, s, 80, "http")
+	parseSqlmap(s, stepData{Address: "10.0.0.11", Port: 80, Scheme: "http", service: srv}, testOutput(t, "sqlmap_80", sqlmapSample))
+
+	urls := map[string]bool{}
+	for _, u := range srv.GetWebURLs(utils.Config.DB) {
+		urls[u.URL] = true
+	}
+	wantURLs := map[string]bool{
+		"http://10.0.0.11:80/item.php?id=1": true,
+		"http://10.0.0.11:80/search.php":    true,
+		"http://10.0.0.11:80/login.php":     true,
+	}
+	if !reflect.DeepEqual(urls, wantURLs) {
+		t.Errorf("urls:\ngot  %v\nwant %v", urls, wantURLs)
+	}
+	wantFindings := map[string]bool{
+		"SQL injection: http://10.0.0.11:80/item.php?id=1 parameter id (GET): boolean-based blind, time-based blind": true,
+		"SQL injection: http://10.0.0.11:80/login.php parameter user (POST): UNION query":                            true,
+	}
+	if findings := testFindings(s); !reflect.DeepEqual(findings, wantFindings) {
+		t.Errorf("findings:\ngot  %v\nwant %v", findings, wantFindings)
+	}
+}
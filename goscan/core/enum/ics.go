@@ -0,0 +1,66 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// ICS / OT
+// ---------------------------------------------------------------------------------------
+// Identification fields of modbus-discover, s7-info, bacnet-info and enip-info which are kept
+var icsFields = map[string]string{
+	"Device identification": "ics_device",
+	"Vendor":                "ics_vendor",
+	"Vendor Name":           "ics_vendor",
+	"Device Type":           "ics_device_type",
+	"Product Name":          "ics_product",
+	"Model Name":            "ics_product",
+	"Module":                "ics_module",
+	"Module Type":           "ics_module_type",
+	"System Name":           "ics_system_name",
+	"Version":               "ics_firmware",
+	"Firmware":              "ics_firmware",
+	"Revision":              "ics_firmware",
+	"Application Software":  "ics_software",
+	"Serial Number":         "ics_serial",
+	"Object Name":           "ics_name",
+	"Location":              "ics_location",
+}
+
+var icsScripts = map[string]string{
+	"modbus-discover": "Modbus",
+	"s7-info":         "S7",
+	"bacnet-info":     "BACnet",
+	"enip-info":       "EtherNet/IP",
+}
+
+// Parse the identification NSE scripts: vendor, product, firmware, etc. are stored as details
+func parseICS(s *EnumScan, data stepData, output string) {
+	for _, sc := range nmapScripts(output) {
+		protocol, ok := icsScripts[sc.ID]
+		if !ok {
+			continue
+		}
+		found := 0
+		for _, line := range strings.Split(sc.Output, "\n") {
+			i := strings.Index(line, ":")
+			if i <= 0 {
+				continue
+			}
+			key, ok := icsFields[strings.TrimSpace(line[:i])]
+			value := strings.TrimSpace(line[i+1:])
+			if !ok || value == "" {
+				continue
+			}
+			model.AddDetail(utils.Config.DB, s.Target, data.Port, protocol, key, value)
+			found++
+		}
+		if found > 0 {
+			s.log().LogNotify(fmt.Sprintf("[ICS] %s device identified on %s:%d", protocol, s.Target.Address, data.Port))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// KERBEROS
+// ---------------------------------------------------------------------------------------
+const (
+	KERBEROS_REALM = "kerberos_realm"
+	KERBEROS_USER  = "kerberos_user"
+)
+
+// "DC=corp,DC=local" -> "corp.local"
+func namingContextToDomain(ctx string) string {
+	labels := []string{}
+	for _, rdn := range strings.Split(ctx, ",") {
+		rdn = strings.TrimSpace(rdn)
+		if len(rdn) > 3 && strings.EqualFold(rdn[:3], "DC=") {
+			labels = append(labels, rdn[3:])
+		}
+	}
+	return strings.ToLower(strings.Join(labels, "."))
+}
+
+// Identify the realm served by the KDC (from the RootDSE or the SMB OS discovery),
+// falling back to what the LDAP enumeration already extracted, and link the host to the domain
+func parseKerberosRealm(s *EnumScan, data stepData, output string) {
+	domain, forest := "", ""
+	for _, sc := range nmapScripts(output) {
+		for _, line := range strings.Split(sc.Output, "\n") {
+			line = strings.TrimSpace(line)
+			switch sc.ID {
+			case "ldap-rootdse":
+				if key, value, ok := splitKeyValue(line); ok && strings.EqualFold(key, "defaultNamingContext") {
+					domain = namingContextToDomain(value)
+				}
+			case "smb-os-discovery":
+				if strings.HasPrefix(line, "Domain name:") {
+					domain = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "Domain name:")))
+				} else if strings.HasPrefix(line, "Forest name:") {
+					forest = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "Forest name:")))
+				}
+			}
+		}
+	}
+	if domain == "" {
+		domain = forest
+	}
+	if domain == "" {
+		if values := s.Target.GetDetailValues(utils.Config.DB, LDAP_DEFAULT_NAMING_CONTEXT); len(values) > 0 {
+			domain = namingContextToDomain(values[0])
+		}
+	}
+	if domain == "" {
+		s.log().LogWarning(fmt.Sprintf("[KERBEROS] Realm of %s:%d not identified", s.Target.Address, data.Port))
+		return
+	}
+
+	realm := strings.ToUpper(domain)
+	model.AddDetail(utils.Config.DB, s.Target, data.Port, "KERBEROS", KERBEROS_REALM, realm)
+	s.Target.SetDomain(utils.Config.DB, domain)
+	s.log().LogNotify(fmt.Sprintf("[KERBEROS] Realm identified on %s:%d: %s", s.Target.Address, data.Port, realm))
+}
+
+// Record the principals confirmed by krb5-enum-users
+func parseKerberosUsers(s *EnumScan, data stepData, output string) {
+	found := 0
+	for _, sc := range nmapScripts(output) {
+		if sc.ID != "krb5-enum-users" {
+			continue
+		}
+		for _, line := range strings.Split(sc.Output, "\n") {
+			principal := strings.TrimSpace(line)
+			if !strings.Contains(principal, "@") || strings.Contains(principal, " ") {
+				continue
+			}
+			model.AddDetail(utils.Config.DB, s.Target, data.Port, "KERBEROS", KERBEROS_USER, strings.ToLower(principal))
+			found++
+		}
+	}
+	if found > 0 {
+		s.log().LogNotify(fmt.Sprintf("[KERBEROS] Found %d valid usernames on %s:%d", found, s.Target.Address, data.Port))
+	}
+}
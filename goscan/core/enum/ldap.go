@@ -0,0 +1,92 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// LDAP / ACTIVE DIRECTORY
+// ---------------------------------------------------------------------------------------
+const (
+	LDAP_NAMING_CONTEXT         = "naming_context"
+	LDAP_DEFAULT_NAMING_CONTEXT = "default_naming_context"
+	LDAP_ROOT_NAMING_CONTEXT    = "root_domain_naming_context"
+	LDAP_DOMAIN_LEVEL           = "domain_functional_level"
+	LDAP_FOREST_LEVEL           = "forest_functional_level"
+	LDAP_DC_LEVEL               = "dc_functional_level"
+	LDAP_DC_HOSTNAME            = "dc_hostname"
+)
+
+// Attributes of the RootDSE (and of the DC objects) which are kept
+var ldapAttributes = map[string]string{
+	"namingcontexts":                LDAP_NAMING_CONTEXT,
+	"defaultnamingcontext":          LDAP_DEFAULT_NAMING_CONTEXT,
+	"rootdomainnamingcontext":       LDAP_ROOT_NAMING_CONTEXT,
+	"domainfunctionality":           LDAP_DOMAIN_LEVEL,
+	"forestfunctionality":           LDAP_FOREST_LEVEL,
+	"domaincontrollerfunctionality": LDAP_DC_LEVEL,
+	"dnshostname":                   LDAP_DC_HOSTNAME,
+}
+
+// msDS-Behavior-Version
+var ldapFunctionalLevels = []string{
+	"Windows 2000",
+	"Windows Server 2003 interim",
+	"Windows Server 2003",
+	"Windows Server 2008",
+	"Windows Server 2008 R2",
+	"Windows Server 2012",
+	"Windows Server 2012 R2",
+	"Windows Server 2016",
+}
+
+func functionalLevel(v string) string {
+	for i, name := range ldapFunctionalLevels {
+		if v == fmt.Sprintf("%d", i) {
+			return fmt.Sprintf("%s (%s)", name, v)
+		}
+	}
+	return v
+}
+
+// Parse the output of ldap-rootdse/ldap-search (nmap XML) or ldapsearch (LDIF),
+// and store naming contexts, functional levels and DC hostnames on the host
+func parseLDAP(s *EnumScan, data stepData, output string) {
+	lines := []string{}
+	if strings.HasSuffix(output, ".xml") {
+		for _, sc := range nmapScripts(output) {
+			if sc.ID == "ldap-rootdse" || sc.ID == "ldap-search" {
+				lines = append(lines, strings.Split(sc.Output, "\n")...)
+			}
+		}
+	} else {
+		lines = readLines(output)
+	}
+
+	found := 0
+	for _, line := range lines {
+		attr, value, ok := splitKeyValue(line)
+		if !ok {
+			continue
+		}
+		key, ok := ldapAttributes[strings.ToLower(attr)]
+		if !ok {
+			continue
+		}
+		switch key {
+		case LDAP_DOMAIN_LEVEL, LDAP_FOREST_LEVEL, LDAP_DC_LEVEL:
+			value = functionalLevel(value)
+		case LDAP_DC_HOSTNAME:
+			value = strings.ToLower(value)
+		}
+		model.AddDetail(utils.Config.DB, s.Target, data.Port, "LDAP", key, value)
+		found++
+	}
+	if found > 0 {
+		s.log().LogNotify(fmt.Sprintf("[LDAP] Extracted %d directory attributes from %s:%d", found, s.Target.Address, data.Port))
+	}
+}
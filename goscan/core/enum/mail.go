@@ -0,0 +1,67 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// MAIL (SMTP, POP3, IMAP)
+// ---------------------------------------------------------------------------------------
+const (
+	MAIL_CAPABILITIES = "mail_capabilities"
+	CERT_SUBJECT      = "cert_subject"
+	CERT_SAN          = "cert_san"
+	CERT_ISSUER       = "cert_issuer"
+	CERT_NOT_AFTER    = "cert_not_after"
+)
+
+// Fields of the ssl-cert NSE script which are kept
+var sslCertFields = map[string]string{
+	"Subject":                  CERT_SUBJECT,
+	"Subject Alternative Name": CERT_SAN,
+	"Issuer":                   CERT_ISSUER,
+	"Not valid after":          CERT_NOT_AFTER,
+}
+
+// Store the certificate presented by the service (directly or after STARTTLS)
+func parseSSLCert(s *EnumScan, port int, source, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			continue
+		}
+		if k, ok := sslCertFields[strings.TrimSpace(line[:i])]; ok {
+			if value := strings.TrimSpace(line[i+1:]); value != "" {
+				model.AddDetail(utils.Config.DB, s.Target, port, source, k, value)
+			}
+		}
+	}
+}
+
+// Parse the output of the mail NSE scripts: capabilities, NTLM info,
+// certificate (STARTTLS), and open relay
+func parseMail(s *EnumScan, data stepData, output string) {
+	for _, sc := range nmapScripts(output) {
+		switch sc.ID {
+		case "smtp-commands", "pop3-capabilities", "imap-capabilities":
+			capabilities := strings.Join(strings.Fields(sc.Output), " ")
+			if capabilities != "" {
+				model.AddDetail(utils.Config.DB, s.Target, data.Port, "MAIL", MAIL_CAPABILITIES, capabilities)
+			}
+		case "smtp-ntlm-info", "pop3-ntlm-info", "imap-ntlm-info":
+			parseNTLMInfo(s, data.Port, "MAIL", sc.Output)
+		case "ssl-cert":
+			parseSSLCert(s, data.Port, "MAIL", sc.Output)
+		case "smtp-open-relay":
+			if strings.Contains(sc.Output, "is an open relay") {
+				model.AddFinding(utils.Config.DB, s.Target, data.Port, "MAIL", model.SEVERITY_HIGH,
+					"SMTP open relay", strings.TrimSpace(strings.Split(strings.TrimSpace(sc.Output), "\n")[0]))
+				s.log().LogNotify(fmt.Sprintf("[MAIL] Open relay on %s:%d", s.Target.Address, data.Port))
+			}
+		}
+	}
+}
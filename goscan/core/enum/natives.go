@@ -0,0 +1,97 @@
+package enum
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// NATIVE PROBES
+// ---------------------------------------------------------------------------------------
+// A native probe talks to the service directly (no external tool), stores its results
+// in the DB, and returns a transcript which is saved as the output of the step
+type nativeProbe func(s *EnumScan, data stepData) (string, error)
+
+// Native probes available to the recipes, by name (`native: <name>`)
+var natives = map[string]nativeProbe{
+	"container": probeContainer,
+	"http":      probeHTTP,
+	"tls":       probeTLS,
+	"snmp":      probeSNMP,
+}
+
+var (
+	probeTimeout   = 10 * time.Second
+	probeBodyLimit = int64(1 << 20)
+)
+
+// Client used by the HTTP probes: certificates are not verified, redirects are not followed
+var probeClient = &http.Client{
+	Timeout: probeTimeout,
+	Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		Proxy:           http.ProxyFromEnvironment,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+type probeResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	TLS    *tls.ConnectionState
+}
+
+// Returns "https" if the service completes a TLS handshake, "http" otherwise
+func detectScheme(address string, port int) string {
+	dialer := &net.Dialer{Timeout: probeTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(address, strconv.Itoa(port)), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return "http"
+	}
+	conn.Close()
+	return "https"
+}
+
+// Send an HTTP request on behalf of the enumeration (audited), and record it in the transcript
+func (s *EnumScan) httpRequest(transcript *bytes.Buffer, method, url string, body []byte) (*probeResponse, error) {
+	if err := s.probeAllowed(); err != nil {
+		fmt.Fprintf(transcript, "%s %s -> %s\n\n", method, url, err)
+		return nil, err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := probeClient.Do(req)
+	utils.AuditProbe(s.job(), []string{method, url}, start, time.Now(), err)
+	if err != nil {
+		fmt.Fprintf(transcript, "%s %s -> %s\n\n", method, url, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, probeBodyLimit))
+	fmt.Fprintf(transcript, "%s %s -> %s\n%s\n\n", method, url, resp.Status, data)
+	return &probeResponse{Status: resp.StatusCode, Header: resp.Header, Body: data, TLS: resp.TLS}, nil
+}
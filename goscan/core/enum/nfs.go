@@ -0,0 +1,98 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// NFS / RPC
+// ---------------------------------------------------------------------------------------
+const (
+	NFS_RPC_SERVICE = "rpc_service"
+	NFS_EXPORT      = "nfs_export"
+	NFS_ACCESS      = "nfs_access"
+)
+
+// ACLs of nfs-showmount meaning "any client"
+var nfsWorldACLs = []string{"*", "0.0.0.0/0", "0.0.0.0/0.0.0.0", "(everyone)", "everyone"}
+
+func isWorldACL(acl string) bool {
+	for _, w := range nfsWorldACLs {
+		if strings.EqualFold(acl, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse the output of rpcinfo, nfs-showmount, nfs-ls (nmap XML): RPC programs,
+// exported shares and their ACLs are stored as details, readable exports as findings
+func parseNFS(s *EnumScan, data stepData, output string) {
+	world, readable := map[string]bool{}, map[string]bool{}
+	for _, sc := range nmapScripts(output) {
+		lines := strings.Split(sc.Output, "\n")
+		switch sc.ID {
+		case "rpcinfo":
+			// program version port/proto service
+			for _, line := range lines {
+				fields := strings.Fields(line)
+				if len(fields) != 4 || len(fields[0]) != 6 || strings.Trim(fields[0], "0123456789") != "" {
+					continue
+				}
+				value := fmt.Sprintf("%s %s v%s %s", fields[0], fields[3], fields[1], fields[2])
+				model.AddDetail(utils.Config.DB, s.Target, data.Port, "NFS", NFS_RPC_SERVICE, value)
+			}
+		case "nfs-showmount":
+			// <path> <acl> [<acl>...]
+			for _, line := range lines {
+				fields := strings.Fields(line)
+				if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+					continue
+				}
+				path, acls := fields[0], fields[1:]
+				if len(acls) == 0 {
+					acls = []string{"*"}
+				}
+				model.AddDetail(utils.Config.DB, s.Target, data.Port, "NFS", NFS_EXPORT, fmt.Sprintf("%s %s", path, strings.Join(acls, " ")))
+				for _, acl := range acls {
+					if isWorldACL(acl) {
+						world[path] = true
+					}
+				}
+			}
+		case "nfs-ls":
+			// Volume <path>, followed by "access: <rights>"
+			volume := ""
+			for _, line := range lines {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "Volume ") {
+					volume = strings.TrimSpace(strings.TrimPrefix(line, "Volume "))
+					continue
+				}
+				if key, value, ok := splitKeyValue(line); ok && key == "access" && volume != "" {
+					model.AddDetail(utils.Config.DB, s.Target, data.Port, "NFS", NFS_ACCESS, fmt.Sprintf("%s: %s", volume, value))
+					// Listed by nfs-ls without credentials
+					for _, right := range strings.Fields(value) {
+						if right == "Read" {
+							readable[volume] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for path := range world {
+		model.AddFinding(utils.Config.DB, s.Target, data.Port, "NFS", model.SEVERITY_HIGH, "World-readable NFS export", path)
+		s.log().LogNotify(fmt.Sprintf("[NFS] World-readable export on %s: %s", s.Target.Address, path))
+	}
+	for path := range readable {
+		if !world[path] {
+			model.AddFinding(utils.Config.DB, s.Target, data.Port, "NFS", model.SEVERITY_MEDIUM, "NFS export readable without credentials", path)
+		}
+	}
+}
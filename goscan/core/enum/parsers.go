@@ -0,0 +1,107 @@
+package enum
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/scan"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// PARSERS
+// ---------------------------------------------------------------------------------------
+// A parser extracts structured results from the output of a recipe step
+// (nmap XML for nmap steps, the output file for commands) and stores them in the DB
+type resultParser func(s *EnumScan, data stepData, output string)
+
+// Parsers available to the recipes, by name (`parse: <name>`)
+var parsers = map[string]resultParser{
+	"ldap":           parseLDAP,
+	"kerberos-realm": parseKerberosRealm,
+	"kerberos-users": parseKerberosUsers,
+	"nfs":            parseNFS,
+	"postgresql":     unauthParser("postgresql"),
+	"mongodb":        unauthParser("mongodb"),
+	"redis":          unauthParser("redis"),
+	"elasticsearch":  unauthParser("elasticsearch"),
+	"couchdb":        unauthParser("couchdb"),
+	"cassandra":      unauthParser("cassandra"),
+	"memcached":      unauthParser("memcached"),
+	"vnc":            parseVNC,
+	"x11":            parseX11,
+	"rdp":            parseRDP,
+	"mail":           parseMail,
+	"ics":            parseICS,
+	"tls":            parseTLS,
+	"enum4linux":     parseEnum4linux,
+	"hydra":          parseHydra,
+	"nikto":          parseNikto,
+	"dirb":           parseDirb,
+	"sqlmap":         parseSqlmap,
+	"onesixtyone":    parseOnesixtyone,
+}
+
+// Output of an NSE script, with the port it ran against (0 for host scripts)
+type scriptOutput struct {
+	Port   int
+	ID     string
+	Output string
+}
+
+// Returns the outputs of the NSE scripts in an nmap XML file
+func nmapScripts(path string) []scriptOutput {
+	res := []scriptOutput{}
+	if _, err := os.Stat(path); err != nil {
+		utils.Config.Log.LogDebug(fmt.Sprintf("Nothing to parse: %s", path))
+		return res
+	}
+	run := scan.ParseOutput(path)
+	if run == nil {
+		return res
+	}
+	for _, h := range run.Hosts {
+		for _, p := range h.Ports {
+			for _, sc := range p.Scripts {
+				res = append(res, scriptOutput{Port: p.PortId, ID: sc.Id, Output: sc.Output})
+			}
+		}
+		for _, sc := range h.HostScripts {
+			res = append(res, scriptOutput{ID: sc.Id, Output: sc.Output})
+		}
+	}
+	return res
+}
+
+// Returns the lines of a text file (nil if it cannot be read)
+func readLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		utils.Config.Log.LogDebug(fmt.Sprintf("Nothing to parse: %s", path))
+		return nil
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// Split a "key: value" line (as printed by NSE scripts and LDIF), ok is false otherwise
+func splitKeyValue(line string) (key, value string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	key = strings.TrimSpace(line[:i])
+	value = strings.TrimSpace(line[i+1:])
+	if key == "" || value == "" || strings.Contains(key, " ") {
+		return "", "", false
+	}
+	return key, value, true
+}
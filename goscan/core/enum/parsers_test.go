@@ -0,0 +1,76 @@
+package enum
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// The parsers store their results in the DB: the tests run in a temporary workspace,
+// with the vault unlocked
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "goscan-enum-test-")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Setenv("OUT_FOLDER", dir)
+	os.Setenv("GOSCAN_PASSPHRASE", "goscan test passphrase")
+	os.Unsetenv("GOSCAN_DB_PATH")
+	os.Unsetenv("GOSCAN_RECIPES")
+	utils.InitConfig()
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// Enumeration of a new host (one per test, so that the results do not mix)
+func testScan(t *testing.T, address string) *EnumScan {
+	t.Helper()
+	if !utils.IsDBAvailable() {
+		t.Skip("database not available")
+	}
+	model.AddHost(utils.Config.DB, address, "up", model.NEW.String())
+	return NewEnumScan(model.GetHostByAddress(utils.Config.DB, address), "TEST", POLITENESS_POLITE)
+}
+
+// Open port of the target, with its service (for the parsers storing URLs on the service)
+func testService(t *testing.T, s *EnumScan, port int, name string) *model.Service {
+	t.Helper()
+	p, _ := model.AddPort(utils.Config.DB, port, "tcp", "open", s.Target)
+	return model.AddService(utils.Config.DB, name, "", "", "", p, p.ID)
+}
+
+// Output of a tool, saved as the steps do
+func testOutput(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(utils.Config.Outfolder, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("cannot write %s: %s", path, err)
+	}
+	return path
+}
+
+// Findings of the host, as "title: detail"
+func testFindings(s *EnumScan) map[string]bool {
+	res := map[string]bool{}
+	for _, f := range s.Target.GetFindings(utils.Config.DB) {
+		res[fmt.Sprintf("%s: %s", f.Title, f.Detail)] = true
+	}
+	return res
+}
+
+// Details of the host, as key -> values
+func testDetails(s *EnumScan) map[string][]string {
+	res := map[string][]string{}
+	for _, d := range s.Target.GetDetails(utils.Config.DB) {
+		res[d.Key] = append(res[d.Key], d.Value)
+	}
+	return res
+}
@@ -1,33 +1,109 @@
 package enum
 
 import (
-	"fmt"
 	"strings"
 
+	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
 )
 
-func (s *EnumScan) EnumRDP() {
-	// Skip if database not available
-	if !utils.IsDBAvailable() {
-		utils.Config.Log.LogWarning("RDP enumeration skipped (database unavailable)")
-		return
+// ---------------------------------------------------------------------------------------
+// RDP
+// ---------------------------------------------------------------------------------------
+const (
+	RDP_SECURITY_LAYER    = "rdp_security_layer"
+	RDP_ENCRYPTION_LEVEL  = "rdp_encryption_level"
+	RDP_ENCRYPTION_METHOD = "rdp_encryption_method"
+)
+
+// Fields of the *-ntlm-info NSE scripts which are kept
+var ntlmFields = map[string]string{
+	"NetBIOS_Domain_Name":   "ntlm_netbios_domain",
+	"NetBIOS_Computer_Name": "ntlm_netbios_computer",
+	"DNS_Domain_Name":       "ntlm_dns_domain",
+	"DNS_Computer_Name":     "ntlm_dns_computer",
+	"DNS_Tree_Name":         "ntlm_dns_tree",
+	"Product_Version":       "ntlm_product_version",
+}
+
+// Store the host/domain names disclosed by an NTLM challenge (rdp-, smtp-, imap-, pop3-ntlm-info),
+// and link the host to its DNS domain if not known yet
+func parseNTLMInfo(s *EnumScan, port int, source, text string) {
+	domain := ""
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := splitKeyValue(line)
+		if !ok {
+			continue
+		}
+		if k, ok := ntlmFields[key]; ok {
+			model.AddDetail(utils.Config.DB, s.Target, port, source, k, value)
+			if key == "DNS_Domain_Name" {
+				domain = strings.ToLower(value)
+			}
+		}
+	}
+	if domain != "" && s.Target.Domain == "" {
+		s.Target.SetDomain(utils.Config.DB, domain)
+	}
+}
+
+// Security layers, encryption level and encryption methods accepted, from the output of
+// rdp-enum-encryption (the "Security layer" block lists the layers, the "RDP Encryption
+// level" block the methods of Native RDP)
+func rdpEncryption(output string) ([]string, string, []string) {
+	layers, methods := []string{}, []string{}
+	level, inMethods := "", false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "Security layer":
+			inMethods = false
+			continue
+		case strings.HasPrefix(line, "RDP Encryption level:"):
+			level = strings.TrimSpace(strings.TrimPrefix(line, "RDP Encryption level:"))
+			inMethods = true
+			continue
+		}
+		i := strings.LastIndex(line, ": SUCCESS")
+		if i <= 0 {
+			continue
+		}
+		if inMethods {
+			methods = append(methods, line[:i])
+		} else {
+			layers = append(layers, line[:i])
+		}
 	}
-	for _, port := range s.Target.GetPorts(utils.Config.DB) {
-		// Enumerate only if port is open
-		if port.Status == "open" {
-			// Dispatch the correct scanner
-			service := port.GetService(utils.Config.DB)
-			if port.Number == 3389 || strings.Contains(strings.ToLower(service.Name), "ms-wbt-server") {
-				// Start Enumerating
-				utils.Config.Log.LogInfo(fmt.Sprintf("Starting Enumeration: %s:%d (%s)", s.Target.Address, port.Number, service.Name))
+	return layers, level, methods
+}
 
-				// -----------------------------------------------------------------------
-				// NMAP
-				// -----------------------------------------------------------------------
-				name := fmt.Sprintf("%s_rdp_nmap_%d", s.Target.Address, port.Number)
-				nmapArgs := fmt.Sprintf("-sV --script=rdp-vuln-ms12-020 -p%d", port.Number)
-				s.runNmap(name, s.Target.Address, "RDP", name, nmapArgs)
+// Parse rdp-enum-encryption and rdp-ntlm-info
+func parseRDP(s *EnumScan, data stepData, output string) {
+	for _, sc := range nmapScripts(output) {
+		switch sc.ID {
+		case "rdp-ntlm-info":
+			parseNTLMInfo(s, data.Port, "RDP", sc.Output)
+		case "rdp-enum-encryption":
+			layers, level, methods := rdpEncryption(sc.Output)
+			if level != "" {
+				model.AddDetail(utils.Config.DB, s.Target, data.Port, "RDP", RDP_ENCRYPTION_LEVEL, level)
+			}
+			accepted := map[string]bool{}
+			for _, layer := range layers {
+				accepted[layer] = true
+				model.AddDetail(utils.Config.DB, s.Target, data.Port, "RDP", RDP_SECURITY_LAYER, layer)
+			}
+			for _, method := range methods {
+				model.AddDetail(utils.Config.DB, s.Target, data.Port, "RDP", RDP_ENCRYPTION_METHOD, method)
+				if strings.HasPrefix(method, "40-bit") || strings.HasPrefix(method, "56-bit") {
+					model.AddFinding(utils.Config.DB, s.Target, data.Port, "RDP", model.SEVERITY_LOW,
+						"RDP accepts weak encryption", method)
+				}
+			}
+			// Without NLA, the login screen is reachable before authenticating
+			if accepted["Native RDP"] || accepted["SSL"] {
+				model.AddFinding(utils.Config.DB, s.Target, data.Port, "RDP", model.SEVERITY_MEDIUM,
+					"RDP does not enforce Network Level Authentication", "Native RDP or SSL security layer accepted")
 			}
 		}
 	}
@@ -0,0 +1,39 @@
+package enum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRDPEncryption(t *testing.T) {
+	output := `
+  Security layer
+    CredSSP (NLA): SUCCESS
+    CredSSP with Early User Auth: SUCCESS
+    Native RDP: SUCCESS
+    RDSTLS: SUCCESS
+    SSL: SUCCESS
+  RDP Encryption level: Client Compatible
+    40-bit RC4: SUCCESS
+    56-bit RC4: SUCCESS
+    128-bit RC4: SUCCESS
+    FIPS 140-1: SUCCESS
+  RDP Protocol Version:  RDP 5.x, 5.1, 5.2, 6.0, 6.1, 7.0, 7.1, 8.0, 8.1, 10.0 server
+`
+	layers, level, methods := rdpEncryption(output)
+	if want := []string{"CredSSP (NLA)", "CredSSP with Early User Auth", "Native RDP", "RDSTLS", "SSL"}; !reflect.DeepEqual(layers, want) {
+		t.Errorf("layers: got %q, want %q", layers, want)
+	}
+	if level != "Client Compatible" {
+		t.Errorf("level: got %q, want Client Compatible", level)
+	}
+	if want := []string{"40-bit RC4", "56-bit RC4", "128-bit RC4", "FIPS 140-1"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("methods: got %q, want %q", methods, want)
+	}
+
+	// NLA only: no encryption level block
+	layers, level, methods = rdpEncryption("\n  Security layer\n    CredSSP (NLA): SUCCESS\n")
+	if !reflect.DeepEqual(layers, []string{"CredSSP (NLA)"}) || level != "" || len(methods) != 0 {
+		t.Errorf("NLA only: got %q %q %q", layers, level, methods)
+	}
+}
@@ -0,0 +1,481 @@
+package enum
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ---------------------------------------------------------------------------------------
+// CONSTANTS
+// ---------------------------------------------------------------------------------------
+const (
+	POLITENESS_POLITE     = "POLITE"     // always run
+	POLITENESS_AGGRESSIVE = "AGGRESSIVE" // skipped in POLITE mode
+)
+
+// Built-in recipes, can be overridden (by kind) by the ones in the user folder
+//
+//go:embed recipes/*.yaml
+var builtinRecipes embed.FS
+
+// ---------------------------------------------------------------------------------------
+// RECIPE STRUCTURE
+// ---------------------------------------------------------------------------------------
+// An enumeration module, defined as data
+type Recipe struct {
+	Kind        string          `yaml:"kind"`
+	Description string          `yaml:"description"`
+	PoliteOnly  bool            `yaml:"polite_only"` // AGGRESSIVE is rejected
+	Warning     string          `yaml:"warning"`     // shown before enumerating
+	Services    []RecipeService `yaml:"services"`
+	Source      string          `yaml:"-"`
+}
+
+// Kinds that never run aggressively, even if a user recipe overriding them omits polite_only
+var politeOnlyKinds = map[string]bool{
+	"ICS": true,
+}
+
+// A set of steps to run against every open port matching the rules
+type RecipeService struct {
+	Name  string       `yaml:"name"`
+	Match RecipeMatch  `yaml:"match"`
+	Steps []RecipeStep `yaml:"steps"`
+}
+
+// A port matches if its number is listed, or if the service name matches the regex
+type RecipeMatch struct {
+	Ports    []int  `yaml:"ports"`
+	Service  string `yaml:"service"`
+	Protocol string `yaml:"protocol"`
+
+	serviceRegex *regexp.Regexp
+}
+
+// Either an nmap scan (switches), an external command (with templated arguments),
+// or a native probe (by name).
+// The output can be handed to a parser, which stores the results in the database
+type RecipeStep struct {
+	Name       string `yaml:"name"`
+	Folder     string `yaml:"folder"`
+	Output     string `yaml:"output"`
+	Nmap       string `yaml:"nmap"`
+	Command    string `yaml:"command"`
+	Native     string `yaml:"native"`
+	Politeness string `yaml:"politeness"`
+	Once       bool   `yaml:"once"`
+	Parse      string `yaml:"parse"`
+
+	output  *template.Template
+	nmap    *template.Template
+	command *template.Template
+}
+
+// Values available to the templates of a step
+type stepData struct {
+	Address  string
+	Port     int
+	Protocol string
+	Service  string
+	Scheme   string
+	Output   string
+
+	host    *model.Host
+	service *model.Service
+}
+
+// First value of a detail already extracted for the host (empty if none),
+// e.g. {{.Detail "kerberos_realm"}}
+func (d stepData) Detail(key string) string {
+	if d.host == nil {
+		return ""
+	}
+	if values := d.host.GetDetailValues(utils.Config.DB, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+var templateFuncs = template.FuncMap{
+	"wordlist": utils.Wordlist,
+}
+
+// ---------------------------------------------------------------------------------------
+// LOADING
+// ---------------------------------------------------------------------------------------
+// Load the built-in recipes, then the ones from the user folder (which take precedence)
+func LoadRecipes(userFolder string) map[string]*Recipe {
+	recipes := map[string]*Recipe{}
+
+	files, _ := builtinRecipes.ReadDir("recipes")
+	for _, f := range files {
+		data, err := builtinRecipes.ReadFile("recipes/" + f.Name())
+		if err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Cannot read built-in recipe %s: %s", f.Name(), err))
+			continue
+		}
+		addRecipe(recipes, data, "built-in")
+	}
+
+	if userFolder == "" {
+		return recipes
+	}
+	paths, err := filepath.Glob(filepath.Join(userFolder, "*.y*ml"))
+	if err != nil {
+		return recipes
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			utils.Config.Log.LogError(fmt.Sprintf("Cannot read recipe %s: %s", path, err))
+			continue
+		}
+		addRecipe(recipes, data, path)
+	}
+	return recipes
+}
+
+func addRecipe(recipes map[string]*Recipe, data []byte, source string) {
+	r, err := ParseRecipe(data)
+	if err != nil {
+		utils.Config.Log.LogError(fmt.Sprintf("Invalid recipe (%s): %s", source, err))
+		return
+	}
+	r.Source = source
+	if prev, ok := recipes[r.Kind]; ok {
+		utils.Config.Log.LogDebug(fmt.Sprintf("Recipe %s from %s overrides %s", r.Kind, source, prev.Source))
+	}
+	recipes[r.Kind] = r
+}
+
+// Parse and validate a recipe
+func ParseRecipe(data []byte) (*Recipe, error) {
+	r := &Recipe{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(r); err != nil {
+		return nil, err
+	}
+
+	r.Kind = strings.ToUpper(strings.TrimSpace(r.Kind))
+	if r.Kind == "" || r.Kind == "ALL" {
+		return nil, fmt.Errorf("missing or reserved kind: %q", r.Kind)
+	}
+	// Fragile devices: polite-only whatever the recipe says (user recipes included)
+	if politeOnlyKinds[r.Kind] {
+		r.PoliteOnly = true
+	}
+	if len(r.Services) == 0 {
+		return nil, fmt.Errorf("%s: no services defined", r.Kind)
+	}
+	for i := range r.Services {
+		srv := &r.Services[i]
+		if srv.Match.Service != "" {
+			re, err := regexp.Compile("(?i)" + srv.Match.Service)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: invalid service regex: %s", r.Kind, srv.Name, err)
+			}
+			srv.Match.serviceRegex = re
+		}
+		if len(srv.Match.Ports) == 0 && srv.Match.serviceRegex == nil {
+			return nil, fmt.Errorf("%s/%s: match must define ports or a service regex", r.Kind, srv.Name)
+		}
+		for j := range srv.Steps {
+			if err := srv.Steps[j].compile(); err != nil {
+				return nil, fmt.Errorf("%s/%s/%s: %s", r.Kind, srv.Name, srv.Steps[j].Name, err)
+			}
+			if r.PoliteOnly && srv.Steps[j].Politeness == POLITENESS_AGGRESSIVE {
+				return nil, fmt.Errorf("%s/%s/%s: aggressive step in a polite-only recipe", r.Kind, srv.Name, srv.Steps[j].Name)
+			}
+		}
+	}
+	return r, nil
+}
+
+func (st *RecipeStep) compile() error {
+	defined := 0
+	for _, v := range []string{st.Nmap, st.Command, st.Native} {
+		if v != "" {
+			defined++
+		}
+	}
+	if defined != 1 {
+		return fmt.Errorf("a step must define one of nmap, command or native")
+	}
+	if _, ok := natives[st.Native]; st.Native != "" && !ok {
+		return fmt.Errorf("unknown native probe: %s", st.Native)
+	}
+	if st.Folder == "" || st.Output == "" {
+		return fmt.Errorf("a step must define its folder and output")
+	}
+	switch st.Politeness {
+	case "":
+		st.Politeness = POLITENESS_POLITE
+	case POLITENESS_POLITE, POLITENESS_AGGRESSIVE:
+	default:
+		return fmt.Errorf("invalid politeness: %s", st.Politeness)
+	}
+	if _, ok := parsers[st.Parse]; st.Parse != "" && !ok {
+		return fmt.Errorf("unknown parser: %s", st.Parse)
+	}
+
+	var err error
+	if st.output, err = template.New("output").Funcs(templateFuncs).Parse(st.Output); err != nil {
+		return err
+	}
+	if st.Nmap != "" {
+		st.nmap, err = template.New("nmap").Funcs(templateFuncs).Parse(st.Nmap)
+	} else if st.Command != "" {
+		st.command, err = template.New("command").Funcs(templateFuncs).Parse(st.Command)
+	}
+	return err
+}
+
+// ---------------------------------------------------------------------------------------
+// EXECUTION
+// ---------------------------------------------------------------------------------------
+func (m *RecipeMatch) matches(port model.Port, service model.Service) bool {
+	if m.Protocol != "" && !strings.EqualFold(m.Protocol, port.Protocol) {
+		return false
+	}
+	for _, n := range m.Ports {
+		if n == port.Number {
+			return true
+		}
+	}
+	return m.serviceRegex != nil && service.Name != "" && m.serviceRegex.MatchString(service.Name)
+}
+
+func render(t *template.Template, data stepData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Kinds renamed by the built-in recipes, still accepted (unless a user recipe defines them)
+var recipeAliases = map[string]string{
+	"SMTP": "MAIL",
+}
+
+// Resolve the renamed kinds of the enumeration
+func resolveKind(recipes map[string]*Recipe, kind string) string {
+	if _, ok := recipes[kind]; ok {
+		return kind
+	}
+	if alias, ok := recipeAliases[kind]; ok {
+		utils.Config.Log.LogWarning(fmt.Sprintf("%s enumeration has been renamed to %s", kind, alias))
+		return alias
+	}
+	return kind
+}
+
+// Any mode other than POLITE and DRY is aggressive
+func isAggressive(polite string) bool {
+	return polite != POLITENESS_POLITE && polite != "DRY"
+}
+
+// Check that the enumeration can start (polite-only recipes reject AGGRESSIVE),
+// and show the warnings of the recipes involved
+func CheckRecipes(recipes map[string]*Recipe, kind, polite string) bool {
+	selected := []*Recipe{}
+	if r, ok := recipes[kind]; ok {
+		if r.PoliteOnly && isAggressive(polite) {
+			utils.Config.Log.LogError(fmt.Sprintf("%s enumeration can only run in POLITE (or DRY) mode", r.Kind))
+			return false
+		}
+		selected = append(selected, r)
+	} else if kind == "ALL" {
+		for _, k := range RecipeKinds(recipes) {
+			if recipes[k].PoliteOnly && isAggressive(polite) {
+				utils.Config.Log.LogWarning(fmt.Sprintf("%s enumeration will be skipped (POLITE mode only)", k))
+				continue
+			}
+			selected = append(selected, recipes[k])
+		}
+	}
+	for _, r := range selected {
+		if r.Warning != "" {
+			utils.WarningBanner(r.Kind, strings.Split(strings.TrimSpace(r.Warning), "\n"))
+			utils.Config.Log.LogWarning(fmt.Sprintf("%s enumeration: %s", r.Kind, strings.Join(strings.Fields(r.Warning), " ")))
+		}
+	}
+	return true
+}
+
+// Run the recipe against every open port of the target
+func (s *EnumScan) runRecipe(r *Recipe) {
+	// Polite-only recipes never run aggressively (e.g., as part of ALL)
+	if r.PoliteOnly && isAggressive(s.Polite) {
+		s.log().LogDebug(fmt.Sprintf("%s enumeration skipped (POLITE mode only)", r.Kind))
+		return
+	}
+
+	// Skip if database not available
+	if !utils.IsDBAvailable() {
+		s.log().LogWarning(fmt.Sprintf("%s enumeration skipped (database unavailable)", r.Kind))
+		return
+	}
+
+	done := map[*RecipeStep]bool{}
+	for _, port := range s.Target.GetPorts(utils.Config.DB) {
+		// Enumerate only if port is open
+		if port.Status != "open" {
+			continue
+		}
+		service := port.GetService(utils.Config.DB)
+		for i := range r.Services {
+			srv := &r.Services[i]
+			if !srv.Match.matches(port, service) {
+				continue
+			}
+			// Start Enumerating
+			s.log().LogInfo(fmt.Sprintf("Starting Enumeration: %s:%d (%s)", s.Target.Address, port.Number, service.Name))
+
+			data := stepData{
+				Address:  s.Target.Address,
+				Port:     port.Number,
+				Protocol: port.Protocol,
+				Service:  service.Name,
+				Scheme:   "http",
+				host:     s.Target,
+				service:  &service,
+			}
+			if strings.Contains(strings.ToLower(service.Name), "https") || strings.Contains(strings.ToLower(service.Name), "ssl/http") {
+				data.Scheme = "https"
+			}
+			for j := range srv.Steps {
+				st := &srv.Steps[j]
+				if st.Once && done[st] {
+					continue
+				}
+				done[st] = true
+				s.runStep(r, st, data)
+			}
+		}
+	}
+}
+
+func (s *EnumScan) runStep(r *Recipe, st *RecipeStep, data stepData) {
+	// Aggressive steps are skipped when being polite
+	if st.Politeness == POLITENESS_AGGRESSIVE && s.Polite == POLITENESS_POLITE {
+		return
+	}
+
+	name, err := render(st.output, data)
+	if err != nil {
+		s.log().LogError(fmt.Sprintf("[%s] Cannot render output of step %s: %s", r.Kind, st.Name, err))
+		return
+	}
+
+	// Nmap scan
+	if st.nmap != nil {
+		nmapArgs, err := render(st.nmap, data)
+		if err != nil {
+			s.log().LogError(fmt.Sprintf("[%s] Cannot render step %s: %s", r.Kind, st.Name, err))
+			return
+		}
+		if strings.TrimSpace(nmapArgs) == "" {
+			s.log().LogInfo(fmt.Sprintf("[%s] Step %s skipped (prerequisites not met yet)", r.Kind, st.Name))
+			return
+		}
+		s.runNmap(name, s.Target.Address, st.Folder, name, nmapArgs)
+		s.parseStep(r, st, data, fmt.Sprintf("%s.xml", s.makeOutputPath(st.Folder, utils.CleanPath(name))))
+		return
+	}
+
+	// Native probe
+	if st.Native != "" {
+		data.Output = s.makeOutputPath(st.Folder, name)
+		s.runNative(st.Native, data)
+		s.parseStep(r, st, data, data.Output)
+		return
+	}
+
+	// External command
+	data.Output = s.makeOutputPath(st.Folder, name)
+	cmd, err := render(st.command, data)
+	if err != nil {
+		s.log().LogError(fmt.Sprintf("[%s] Cannot render step %s: %s", r.Kind, st.Name, err))
+		return
+	}
+	if strings.TrimSpace(cmd) == "" {
+		s.log().LogInfo(fmt.Sprintf("[%s] Step %s skipped (prerequisites not met yet)", r.Kind, st.Name))
+		return
+	}
+	s.runCmd(cmd)
+	s.parseStep(r, st, data, data.Output)
+}
+
+// Hand the output of a step to its parser (if any).
+// Credentials found by NSE scripts are extracted from the output of every nmap step
+func (s *EnumScan) parseStep(r *Recipe, st *RecipeStep, data stepData, output string) {
+	if s.Polite == "DRY" || s.Status == model.CANCELLED {
+		return
+	}
+	if st.nmap != nil {
+		parseNSECredentials(s, data, output)
+	}
+	if st.Parse == "" {
+		return
+	}
+	s.log().LogDebug(fmt.Sprintf("[%s] Parsing output of step %s: %s", r.Kind, st.Name, output))
+	parsers[st.Parse](s, data, output)
+}
+
+// Returns the kinds of the available recipes, sorted
+func RecipeKinds(recipes map[string]*Recipe) []string {
+	kinds := make([]string, 0, len(recipes))
+	for k := range recipes {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Recipes loaded from the current folders: parsed once (the completer asks for them on
+// every keystroke), then again when the recipes or output folder changes
+var (
+	recipesCache map[string]*Recipe
+	recipesLock  sync.Mutex
+)
+
+// Returns the recipes currently available (built-in and from the user folder)
+func GetRecipes() map[string]*Recipe {
+	recipesLock.Lock()
+	defer recipesLock.Unlock()
+	if recipesCache == nil {
+		recipesCache = loadCurrentRecipes()
+	}
+	return recipesCache
+}
+
+// Load the recipes again, from the current user folder
+func ReloadRecipes() map[string]*Recipe {
+	recipesLock.Lock()
+	defer recipesLock.Unlock()
+	recipesCache = loadCurrentRecipes()
+	return recipesCache
+}
+
+func loadCurrentRecipes() map[string]*Recipe {
+	if _, err := os.Stat(utils.Config.RecipesFolder); err != nil {
+		return LoadRecipes("")
+	}
+	return LoadRecipes(utils.Config.RecipesFolder)
+}
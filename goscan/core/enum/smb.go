@@ -2,65 +2,165 @@ package enum
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
 )
 
-func (s *EnumScan) EnumSMB() {
-	// Skip if database not available
-	if !utils.IsDBAvailable() {
-		utils.Config.Log.LogWarning("SMB enumeration skipped (database unavailable)")
-		return
-	}
-	for _, port := range s.Target.GetPorts(utils.Config.DB) {
-		// Enumerate only if port is open
-		if port.Status == "open" {
-			// Dispatch the correct scanner
-			service := port.GetService(utils.Config.DB)
-			if port.Number == 139 || port.Number == 445 ||
-				strings.Contains(strings.ToLower(service.Name), "smb") ||
-				strings.Contains(strings.ToLower(service.Name), "microsoft-ds") ||
-				strings.Contains(strings.ToLower(service.Name), "netbios") {
-				// Start Enumerating
-				utils.Config.Log.LogInfo(fmt.Sprintf("Starting Enumeration: %s:%d (%s)", s.Target.Address, port.Number, service.Name))
+// ---------------------------------------------------------------------------------------
+// SMB (enum4linux)
+// ---------------------------------------------------------------------------------------
+const (
+	SMB_WORKGROUP  = "smb_workgroup"
+	SMB_OS         = "smb_os"
+	SMB_SERVER     = "smb_server"
+	SMB_DOMAIN_SID = "smb_domain_sid"
 
-				// -----------------------------------------------------------------------
-				// NMAP
-				// -----------------------------------------------------------------------
-				name := fmt.Sprintf("%s_smb_%d_nmap", s.Target.Address, port.Number)
-				nmapArgs := fmt.Sprintf("-v -p 137,138,139,445%d --script=smb-os-discovery,smb-security-mode,smb-psexec,smb-mbenum,smb-enum-shares,smb-enum-sessions,smb-enum-processes,samba-vuln-cve-2012-1182,smb-check-vulns,nbtstat  --script-args=unsafe=1", port.Number)
-				s.runNmap(name, s.Target.Address, "SMB", name, nmapArgs)
+	SMB_MIN_PASSWORD_LENGTH = "smb_min_password_length"
+	SMB_LOCKOUT_THRESHOLD   = "smb_lockout_threshold"
 
-				name = fmt.Sprintf("%s_smb_%d_nmap_enum-users", s.Target.Address, port.Number)
-				nmapArgs = fmt.Sprintf("-v -p 137,138,139,445%d --script=smb-enum-users -sS -A", port.Number)
-				s.runNmap(name, s.Target.Address, "SMB", name, nmapArgs)
+	smbMinPasswordLength = 8
+)
 
-				name = fmt.Sprintf("%s_smb_%d_nmap_nbtstat", s.Target.Address, port.Number)
-				nmapArgs = fmt.Sprintf("-v -p 137,138,139,445%d -sU --script nbstat.nse", port.Number)
-				s.runNmap(name, s.Target.Address, "SMB", name, nmapArgs)
+// Fields of the password policy which are kept
+var smbPolicyFields = map[string]string{
+	"Minimum password length":       SMB_MIN_PASSWORD_LENGTH,
+	"Password history length":       "smb_password_history",
+	"Maximum password age":          "smb_max_password_age",
+	"Minimum password age":          "smb_min_password_age",
+	"Password Complexity Flags":     "smb_password_complexity",
+	"Account Lockout Threshold":     SMB_LOCKOUT_THRESHOLD,
+	"Locked Account Duration":       "smb_lockout_duration",
+	"Reset Account Lockout Counter": "smb_lockout_reset",
+}
+
+var (
+	e4lWorkgroup  = regexp.MustCompile(`^\[\+\] Got domain/workgroup name: (\S+)`)
+	e4lOS         = regexp.MustCompile(`OS=\[([^\]]*)\] Server=\[([^\]]*)\]`)
+	e4lDomainSid  = regexp.MustCompile(`^Domain Sid: (S-1-5-21-[0-9-]+)`)
+	e4lUserIndex  = regexp.MustCompile(`^index: \S+ RID: (0x[0-9a-fA-F]+) acb: \S+ Account: (.*?)\tName: (.*?)\tDesc: (.*)$`)
+	e4lUser       = regexp.MustCompile(`^user:\[([^\]]+)\] rid:\[(0x[0-9a-fA-F]+)\]`)
+	e4lGroup      = regexp.MustCompile(`^group:\[([^\]]+)\] rid:\[(0x[0-9a-fA-F]+)\]`)
+	e4lGroupKind  = regexp.MustCompile(`^\[\+\] Getting (builtin|local|domain) groups:`)
+	e4lMember     = regexp.MustCompile(`^Group '(.+)' \(RID: (\d+)\) has member: (.+)$`)
+	e4lShare      = regexp.MustCompile(`^\s+(\S+)\s+(Disk|IPC|Printer)\s*(.*)$`)
+	e4lShareMap   = regexp.MustCompile(`^//[^/]+/(.+?)\s+Mapping: (\S+), Listing: (\S+)`)
+	e4lPolicy     = regexp.MustCompile(`^\s*\[\+\] ([A-Za-z ]+): (.+?)\s*$`)
+	e4lRIDCycling = regexp.MustCompile(`^S-1-5-21-[0-9-]+-(\d+) (.+?)\\(.+) \((Local User|Domain User|Local Group|Domain Group)\)$`)
+)
 
-				// -----------------------------------------------------------------------
-				// ENUM4LINUX
-				// -----------------------------------------------------------------------
-				output := s.makeOutputPath("SMB", fmt.Sprintf("%s_enum4linux", s.Target.Address))
-				cmd := fmt.Sprintf("enum4linux -a %s > %s", s.Target.Address, output)
-				s.runCmd(cmd)
+// Parse a RID in hex (0x1f4) or decimal notation
+func parseRID(s string) int {
+	rid, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return 0
+	}
+	return int(rid)
+}
 
-				// -----------------------------------------------------------------------
-				// NBTSCAN
-				// -----------------------------------------------------------------------
-				output = s.makeOutputPath("SMB", fmt.Sprintf("%s_nbtscan", s.Target.Address))
-				cmd = fmt.Sprintf("nbtscan -r %s > %s", s.Target.Address, output)
-				s.runCmd(cmd)
+// Parse enum4linux output: OS/workgroup and password policy (details), users with RIDs,
+// groups and memberships, and shares with the access obtained over the null session
+func parseEnum4linux(s *EnumScan, data stepData, output string) {
+	db := utils.Config.DB
+	lines := readLines(output)
+	workgroup := ""
+	groupKind := ""
+	users, groups, shares := 0, 0, 0
+	policy := map[string]string{}
 
-				// -----------------------------------------------------------------------
-				// SAMRDUMP
-				// -----------------------------------------------------------------------
-				output = s.makeOutputPath("SMB", fmt.Sprintf("%s_samrdump", s.Target.Address))
-				cmd = fmt.Sprintf("python /usr/local/bin/samrdump.py %s 445/SMB > %s", s.Target.Address, output)
-				s.runCmd(cmd)
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case e4lWorkgroup.MatchString(line):
+			workgroup = e4lWorkgroup.FindStringSubmatch(line)[1]
+			model.AddDetail(db, s.Target, data.Port, "SMB", SMB_WORKGROUP, workgroup)
+		case e4lOS.MatchString(line):
+			m := e4lOS.FindStringSubmatch(line)
+			if m[1] != "" {
+				model.AddDetail(db, s.Target, data.Port, "SMB", SMB_OS, m[1])
+			}
+			if m[2] != "" {
+				model.AddDetail(db, s.Target, data.Port, "SMB", SMB_SERVER, m[2])
+			}
+		case e4lDomainSid.MatchString(line):
+			model.AddDetail(db, s.Target, data.Port, "SMB", SMB_DOMAIN_SID, e4lDomainSid.FindStringSubmatch(line)[1])
+		case e4lUserIndex.MatchString(line):
+			m := e4lUserIndex.FindStringSubmatch(line)
+			model.AddDomainUser(db, s.Target, workgroup, m[2], parseRID(m[1]), nullable(m[3]), nullable(m[4]))
+			users++
+		case e4lUser.MatchString(line):
+			m := e4lUser.FindStringSubmatch(line)
+			model.AddDomainUser(db, s.Target, workgroup, m[1], parseRID(m[2]), "", "")
+			users++
+		case e4lGroupKind.MatchString(line):
+			groupKind = e4lGroupKind.FindStringSubmatch(line)[1]
+		case e4lGroup.MatchString(line):
+			m := e4lGroup.FindStringSubmatch(line)
+			model.AddDomainGroup(db, s.Target, m[1], parseRID(m[2]), groupKind)
+			groups++
+		case e4lMember.MatchString(line):
+			m := e4lMember.FindStringSubmatch(line)
+			g := model.AddDomainGroup(db, s.Target, m[1], parseRID(m[2]), "")
+			g.AddMember(db, m[3])
+		case e4lRIDCycling.MatchString(line):
+			m := e4lRIDCycling.FindStringSubmatch(line)
+			if strings.HasSuffix(m[4], "User") {
+				model.AddDomainUser(db, s.Target, m[2], m[3], parseRID(m[1]), "", "")
+				users++
+			} else {
+				kind := model.GROUP_LOCAL
+				if strings.HasPrefix(m[4], "Domain") {
+					kind = model.GROUP_DOMAIN
+				}
+				model.AddDomainGroup(db, s.Target, m[3], parseRID(m[1]), kind)
+				groups++
+			}
+		case e4lShareMap.MatchString(line):
+			m := e4lShareMap.FindStringSubmatch(line)
+			access := fmt.Sprintf("Mapping: %s, Listing: %s", m[2], m[3])
+			model.AddShare(db, s.Target, m[1], "", "", access)
+			if m[2] == "OK" && m[3] == "OK" && !strings.HasSuffix(m[1], "$") {
+				model.AddFinding(db, s.Target, data.Port, "SMB", model.SEVERITY_MEDIUM,
+					"SMB share readable without credentials", fmt.Sprintf("//%s/%s", s.Target.Address, m[1]))
 			}
+		case e4lShare.MatchString(line):
+			m := e4lShare.FindStringSubmatch(line)
+			model.AddShare(db, s.Target, m[1], m[2], strings.TrimSpace(m[3]), "")
+			shares++
+		case e4lPolicy.MatchString(line):
+			m := e4lPolicy.FindStringSubmatch(line)
+			if key, ok := smbPolicyFields[m[1]]; ok {
+				policy[key] = m[2]
+				model.AddDetail(db, s.Target, data.Port, "SMB", key, m[2])
+			}
+		}
+	}
+
+	// Weak password policy
+	if v, ok := policy[SMB_LOCKOUT_THRESHOLD]; ok && (v == "None" || v == "0") {
+		model.AddFinding(db, s.Target, data.Port, "SMB", model.SEVERITY_MEDIUM,
+			"No account lockout policy", "Account Lockout Threshold: "+v)
+	}
+	if v, ok := policy[SMB_MIN_PASSWORD_LENGTH]; ok {
+		if n, err := strconv.Atoi(v); (err == nil && n < smbMinPasswordLength) || v == "None" {
+			model.AddFinding(db, s.Target, data.Port, "SMB", model.SEVERITY_LOW,
+				"Weak password length policy", "Minimum password length: "+v)
 		}
 	}
+
+	if users+groups+shares > 0 {
+		s.log().LogNotify(fmt.Sprintf("[SMB] %s: %d users, %d groups, %d shares", s.Target.Address, users, groups, shares))
+	}
+}
+
+// enum4linux prints empty fields as "(null)"
+func nullable(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "(null)" {
+		return ""
+	}
+	return s
 }
@@ -0,0 +1,190 @@
+package enum
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// enum4linux -a against a domain controller allowing null sessions
+var enum4linuxSample = strings.Join([]string{
+	" ============================================== ",
+	"|    Enumerating Workgroup/Domain on 10.0.0.5    |",
+	" ============================================== ",
+	"[+] Got domain/workgroup name: CORP",
+	"",
+	" =================================== ",
+	"|    Getting domain SID for 10.0.0.5    |",
+	" =================================== ",
+	"Domain Name: CORP",
+	"Domain Sid: S-1-5-21-1004336348-1177238915-682003330",
+	"[+] Host is part of a domain (not a workgroup)",
+	"",
+	"[+] Got OS info for 10.0.0.5 from smbclient: Domain=[CORP] OS=[Windows Server 2016 Standard 14393] Server=[Windows Server 2016 Standard 6.3]",
+	"",
+	" =========================== ",
+	"|    Users on 10.0.0.5    |",
+	" =========================== ",
+	"index: 0x101 RID: 0x1f4 acb: 0x00000010 Account: Administrator\tName: (null)\tDesc: Built-in account for administering the computer/domain",
+	"index: 0x102 RID: 0x44f acb: 0x00000010 Account: jsmith\tName: John Smith\tDesc: (null)",
+	"",
+	"user:[Administrator] rid:[0x1f4]",
+	"user:[jsmith] rid:[0x44f]",
+	"user:[svc_backup] rid:[0x450]",
+	"",
+	" ======================================= ",
+	"|    Share Enumeration on 10.0.0.5    |",
+	" ======================================= ",
+	"",
+	"\tSharename       Type      Comment",
+	"\t---------       ----      -------",
+	"\tADMIN$          Disk      Remote Admin",
+	"\tC$              Disk      Default share",
+	"\tIPC$            IPC       Remote IPC",
+	"\tPublic          Disk      Public files",
+	"SMB1 disabled -- no workgroup available",
+	"",
+	"[+] Attempting to map shares on 10.0.0.5",
+	"//10.0.0.5/ADMIN$\tMapping: DENIED, Listing: N/A",
+	"//10.0.0.5/C$\tMapping: DENIED, Listing: N/A",
+	"//10.0.0.5/IPC$\t[E] Can't understand response:",
+	"//10.0.0.5/Public\tMapping: OK, Listing: OK",
+	"",
+	"[+] Password Info for Domain: CORP",
+	"",
+	"\t[+] Minimum password length: 5",
+	"\t[+] Password history length: None",
+	"\t[+] Maximum password age: 41 days 23 hours 53 minutes ",
+	"\t[+] Password Complexity Flags: 000000",
+	"\t[+] Minimum password age: None",
+	"\t[+] Reset Account Lockout Counter: 30 minutes ",
+	"\t[+] Locked Account Duration: 30 minutes ",
+	"\t[+] Account Lockout Threshold: None",
+	"\t[+] Forced Log off Time: Not Set",
+	"",
+	"[+] Getting builtin groups:",
+	"group:[Administrators] rid:[0x220]",
+	"group:[Users] rid:[0x221]",
+	"",
+	"[+] Getting builtin group memberships:",
+	"Group 'Administrators' (RID: 544) has member: CORP\\Administrator",
+	"",
+	"[+] Getting domain groups:",
+	"group:[Domain Admins] rid:[0x200]",
+	"",
+	"[+] Getting domain group memberships:",
+	"Group 'Domain Admins' (RID: 512) has member: CORP\\Administrator",
+	"Group 'Domain Admins' (RID: 512) has member: CORP\\jsmith",
+	"",
+	"[+] Enumerating users using SID S-1-5-21-1004336348-1177238915-682003330 and logon username '', password ''",
+	"S-1-5-21-1004336348-1177238915-682003330-500 CORP\\Administrator (Local User)",
+	"S-1-5-21-1004336348-1177238915-682003330-513 CORP\\Domain Users (Domain Group)",
+	"S-1-5-21-1004336348-1177238915-682003330-1105 CORP\\helpdesk (Local User)",
+	"",
+}, "\r\n")
+
+func TestParseEnum4linux(t *testing.T) {
+	s := testScan(t, "10.0.0.5")
+	parseEnum4linux(s, stepData{Address: "10.0.0.5", Port: 445}, testOutput(t, "enum4linux", enum4linuxSample))
+	db := utils.Config.DB
+
+	details := testDetails(s)
+	for key, want := range map[string]string{
+		SMB_WORKGROUP:             "CORP",
+		SMB_OS:                    "Windows Server 2016 Standard 14393",
+		SMB_SERVER:                "Windows Server 2016 Standard 6.3",
+		SMB_DOMAIN_SID:            "S-1-5-21-1004336348-1177238915-682003330",
+		SMB_MIN_PASSWORD_LENGTH:   "5",
+		SMB_LOCKOUT_THRESHOLD:     "None",
+		"smb_password_history":    "None",
+		"smb_max_password_age":    "41 days 23 hours 53 minutes",
+		"smb_password_complexity": "000000",
+		"smb_lockout_duration":    "30 minutes",
+	} {
+		if !reflect.DeepEqual(details[key], []string{want}) {
+			t.Errorf("detail %s: got %q, want %q", key, details[key], want)
+		}
+	}
+	if _, ok := details["Forced Log off Time"]; ok {
+		t.Errorf("unexpected policy field kept: %v", details)
+	}
+
+	type user struct {
+		Domain, Username string
+		RID              int
+		FullName, Desc   string
+	}
+	users := []user{}
+	for _, u := range s.Target.GetDomainUsers(db) {
+		users = append(users, user{u.Domain, u.Username, u.RID, u.FullName, u.Description})
+	}
+	wantUsers := []user{
+		{"CORP", "Administrator", 500, "", "Built-in account for administering the computer/domain"},
+		{"CORP", "jsmith", 1103, "John Smith", ""},
+		{"CORP", "svc_backup", 1104, "", ""},
+		{"CORP", "helpdesk", 1105, "", ""},
+	}
+	if !reflect.DeepEqual(users, wantUsers) {
+		t.Errorf("users:\ngot  %+v\nwant %+v", users, wantUsers)
+	}
+
+	type group struct {
+		Name    string
+		RID     int
+		Kind    string
+		Members []string
+	}
+	groups := []group{}
+	for _, g := range s.Target.GetDomainGroups(db) {
+		groups = append(groups, group{g.Name, g.RID, g.Kind, g.GetMembers(db)})
+	}
+	wantGroups := []group{
+		{"Administrators", 544, model.GROUP_BUILTIN, []string{"CORP\\Administrator"}},
+		{"Users", 545, model.GROUP_BUILTIN, []string{}},
+		{"Domain Admins", 512, model.GROUP_DOMAIN, []string{"CORP\\Administrator", "CORP\\jsmith"}},
+		{"Domain Users", 513, model.GROUP_DOMAIN, []string{}},
+	}
+	if len(groups) != len(wantGroups) {
+		t.Fatalf("groups:\ngot  %+v\nwant %+v", groups, wantGroups)
+	}
+	for _, want := range wantGroups {
+		found := false
+		for _, g := range groups {
+			if g.Name == want.Name {
+				found = true
+				if !reflect.DeepEqual(g, want) {
+					t.Errorf("group %s: got %+v, want %+v", want.Name, g, want)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("group %s not found", want.Name)
+		}
+	}
+
+	shares := map[string]string{}
+	for _, sh := range s.Target.GetShares(db) {
+		shares[sh.Name] = strings.Join([]string{sh.Type, sh.Comment, sh.Access}, "|")
+	}
+	wantShares := map[string]string{
+		"ADMIN$": "Disk|Remote Admin|Mapping: DENIED, Listing: N/A",
+		"C$":     "Disk|Default share|Mapping: DENIED, Listing: N/A",
+		"IPC$":   "IPC|Remote IPC|",
+		"Public": "Disk|Public files|Mapping: OK, Listing: OK",
+	}
+	if !reflect.DeepEqual(shares, wantShares) {
+		t.Errorf("shares:\ngot  %q\nwant %q", shares, wantShares)
+	}
+
+	wantFindings := map[string]bool{
+		"SMB share readable without credentials: //10.0.0.5/Public":  true,
+		"No account lockout policy: Account Lockout Threshold: None": true,
+		"Weak password length policy: Minimum password length: 5":    true,
+	}
+	if findings := testFindings(s); !reflect.DeepEqual(findings, wantFindings) {
+		t.Errorf("findings:\ngot  %v\nwant %v", findings, wantFindings)
+	}
+}
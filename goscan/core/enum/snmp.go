@@ -1,83 +1,386 @@
 package enum
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gosnmp/gosnmp"
+	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
 )
 
-func (s *EnumScan) EnumSNMP() {
-	// Skip if database not available
-	if !utils.IsDBAvailable() {
-		utils.Config.Log.LogWarning("SNMP enumeration skipped (database unavailable)")
-		return
-	}
-	for _, port := range s.Target.GetPorts(utils.Config.DB) {
-		// Enumerate only if port is open
-		if port.Status == "open" {
-			// Dispatch the correct scanner
-			service := port.GetService(utils.Config.DB)
-			if port.Number == 161 || strings.Contains(strings.ToLower(service.Name), "snmp") {
-				// Start Enumerating
-				utils.Config.Log.LogInfo(fmt.Sprintf("Starting Enumeration: %s:%d (%s)", s.Target.Address, port.Number, service.Name))
-
-				// -----------------------------------------------------------------------
-				// NMAP
-				// -----------------------------------------------------------------------
-				name := fmt.Sprintf("%s_snmp_%d_nmap", s.Target.Address, port.Number)
-				nmapArgs := fmt.Sprintf("-sV -Pn --script=snmp-netstat,snmp-processes,snmp-win32-services,snmp-win32-shares,snmp-win32-software,snmp-win32-users -p161,162,%d", port.Number)
-				s.runNmap(name, s.Target.Address, "SNMP", name, nmapArgs)
-
-				// -----------------------------------------------------------------------
-				// SNMPCHECK
-				// -----------------------------------------------------------------------
-				output := s.makeOutputPath("SNMP", fmt.Sprintf("%s_snmp_%d_snmpcheck", s.Target.Address, port.Number))
-				cmd := fmt.Sprintf("snmpcheck %s > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				// -----------------------------------------------------------------------
-				// ONESIXTYONE
-				// -----------------------------------------------------------------------
-				output = s.makeOutputPath("SNMP", fmt.Sprintf("%s_snmp_%d_onesixtyone", s.Target.Address, port.Number))
-				cmd = fmt.Sprintf("onesixtyone -c %s %s > %s", utils.WORDLIST_SNMP, s.Target.Address, output)
-				s.runCmd(cmd)
-
-				// -----------------------------------------------------------------------
-				// SNMPWALK
-				// -----------------------------------------------------------------------
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_1")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_system-processes")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.2.1.25.1.6.0 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_running-programs")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.2.1.25.4.2.1.2 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_processes-path")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.2.1.25.4.2.1.4 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_storage-units")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.2.1.25.2.3.1.4 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_installed-software")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.2.1.25.6.3.1.2 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_user-accounts")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.4.1.77.1.2.25 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
-
-				output = s.makeOutputPath("SNMP", "snmp_snmpwalk_open-tcp-ports")
-				cmd = fmt.Sprintf("snmpwalk -c public -v1 %s 1.3.6.1.2.1.6.13.1.3 > %s", s.Target.Address, output)
-				s.runCmd(cmd)
+// ---------------------------------------------------------------------------------------
+// SNMP
+// ---------------------------------------------------------------------------------------
+const (
+	SNMP_COMMUNITY = "snmp_community"
+	SNMP_VERSION   = "snmp_version"
+	SNMP_SYSDESCR  = "snmp_sysdescr"
+	SNMP_SYSNAME   = "snmp_sysname"
+	SNMP_CONTACT   = "snmp_contact"
+	SNMP_LOCATION  = "snmp_location"
+	SNMP_PROCESSES = "snmp_processes"
+
+	snmpTimeout = 3 * time.Second
+)
+
+// OIDs walked by the native SNMP client (the ones of the former snmpwalk steps,
+// plus the ones needed to rebuild listeners and interfaces)
+const (
+	oidSysDescr        = ".1.3.6.1.2.1.1.1.0"
+	oidSysContact      = ".1.3.6.1.2.1.1.4.0"
+	oidSysName         = ".1.3.6.1.2.1.1.5.0"
+	oidSysLocation     = ".1.3.6.1.2.1.1.6.0"
+	oidSystemProcesses = ".1.3.6.1.2.1.25.1.6.0"
+	oidRunName         = ".1.3.6.1.2.1.25.4.2.1.2"
+	oidRunPath         = ".1.3.6.1.2.1.25.4.2.1.4"
+	oidRunParameters   = ".1.3.6.1.2.1.25.4.2.1.5"
+	oidStorageDescr    = ".1.3.6.1.2.1.25.2.3.1.3"
+	oidStorageUnits    = ".1.3.6.1.2.1.25.2.3.1.4"
+	oidInstalledName   = ".1.3.6.1.2.1.25.6.3.1.2"
+	oidLanManUsers     = ".1.3.6.1.4.1.77.1.2.25"
+	oidTCPConnState    = ".1.3.6.1.2.1.6.13.1.1"
+	oidUDPLocalPort    = ".1.3.6.1.2.1.7.5.1.2"
+	oidIfDescr         = ".1.3.6.1.2.1.2.2.1.2"
+	oidIfPhysAddress   = ".1.3.6.1.2.1.2.2.1.6"
+	oidIPAdEntIfIndex  = ".1.3.6.1.2.1.4.20.1.2"
+	oidIPAdEntNetMask  = ".1.3.6.1.2.1.4.20.1.3"
+
+	tcpStateListen = 2
+)
+
+// ---------------------------------------------------------------------------------------
+// Communities (onesixtyone)
+// ---------------------------------------------------------------------------------------
+var onesixtyoneResult = regexp.MustCompile(`^\S+ \[(.+)\] `)
+
+// Parse onesixtyone output: the communities the host answered to
+func parseOnesixtyone(s *EnumScan, data stepData, output string) {
+	for _, line := range readLines(output) {
+		if m := onesixtyoneResult.FindStringSubmatch(line); m != nil {
+			model.AddDetail(utils.Config.DB, s.Target, data.Port, "SNMP", SNMP_COMMUNITY, m[1])
+		}
+	}
+}
+
+// Communities to try: the ones found by onesixtyone, or the SNMP wordlist
+func (s *EnumScan) snmpCommunities() []string {
+	communities := s.Target.GetDetailValues(utils.Config.DB, SNMP_COMMUNITY)
+	if len(communities) > 0 {
+		return communities
+	}
+	s.log().LogInfo(fmt.Sprintf("[SNMP] No community found by onesixtyone on %s, trying the SNMP wordlist", s.Target.Address))
+	for _, line := range readLines(utils.WORDLIST_SNMP) {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			communities = append(communities, line)
+		}
+	}
+	return communities
+}
+
+// ---------------------------------------------------------------------------------------
+// Client
+// ---------------------------------------------------------------------------------------
+func snmpVersionName(v gosnmp.SnmpVersion) string {
+	switch v {
+	case gosnmp.Version1:
+		return "v1"
+	case gosnmp.Version2c:
+		return "v2c"
+	}
+	return "v3"
+}
+
+// SNMPv3 client with the credentials of "set snmp_v3"
+func snmpV3Client(address string, port int) (*gosnmp.GoSNMP, error) {
+	creds := utils.SNMP_V3
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:                 creds.User,
+		AuthenticationProtocol:   gosnmp.NoAuth,
+		AuthenticationPassphrase: creds.AuthPassphrase,
+		PrivacyProtocol:          gosnmp.NoPriv,
+		PrivacyPassphrase:        creds.PrivPassphrase,
+	}
+	flags := gosnmp.NoAuthNoPriv
+	if creds.AuthProtocol != "" {
+		flags = gosnmp.AuthNoPriv
+		usm.AuthenticationProtocol = 0
+		for _, p := range []gosnmp.SnmpV3AuthProtocol{gosnmp.MD5, gosnmp.SHA, gosnmp.SHA224, gosnmp.SHA256, gosnmp.SHA384, gosnmp.SHA512} {
+			if strings.EqualFold(p.String(), creds.AuthProtocol) {
+				usm.AuthenticationProtocol = p
+			}
+		}
+		if usm.AuthenticationProtocol == 0 {
+			return nil, fmt.Errorf("unknown SNMPv3 authentication protocol: %s", creds.AuthProtocol)
+		}
+	}
+	if creds.PrivProtocol != "" {
+		flags = gosnmp.AuthPriv
+		usm.PrivacyProtocol = 0
+		for _, p := range []gosnmp.SnmpV3PrivProtocol{gosnmp.DES, gosnmp.AES, gosnmp.AES192, gosnmp.AES256, gosnmp.AES192C, gosnmp.AES256C} {
+			if strings.EqualFold(p.String(), creds.PrivProtocol) {
+				usm.PrivacyProtocol = p
+			}
+		}
+		if usm.PrivacyProtocol == 0 {
+			return nil, fmt.Errorf("unknown SNMPv3 privacy protocol: %s", creds.PrivProtocol)
+		}
+	}
+	return &gosnmp.GoSNMP{
+		Target:             address,
+		Port:               uint16(port),
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           flags,
+		SecurityParameters: usm,
+		Timeout:            snmpTimeout,
+		Retries:            1,
+		MaxRepetitions:     25,
+	}, nil
+}
+
+// Connect and read sysDescr, audited (the community is not recorded)
+func (s *EnumScan) snmpTry(transcript *bytes.Buffer, client *gosnmp.GoSNMP) bool {
+	address := net.JoinHostPort(client.Target, strconv.Itoa(int(client.Port)))
+	if err := s.probeAllowed(); err != nil {
+		fmt.Fprintf(transcript, "%s %s -> %s\n", address, snmpVersionName(client.Version), err)
+		return false
+	}
+	start := time.Now()
+	err := client.Connect()
+	if err == nil {
+		var res *gosnmp.SnmpPacket
+		res, err = client.Get([]string{oidSysDescr})
+		if err == nil && (len(res.Variables) == 0 || res.Variables[0].Type == gosnmp.NoSuchObject || res.Variables[0].Type == gosnmp.NoSuchInstance) {
+			err = fmt.Errorf("no sysDescr")
+		}
+	}
+	utils.AuditProbe(s.job(), []string{"SNMP", address, snmpVersionName(client.Version), "get", oidSysDescr}, start, time.Now(), err)
+	fmt.Fprintf(transcript, "%s %s -> ", address, snmpVersionName(client.Version))
+	if err != nil {
+		fmt.Fprintf(transcript, "%s\n", err)
+		if client.Conn != nil {
+			client.Conn.Close()
+		}
+		return false
+	}
+	fmt.Fprintf(transcript, "OK\n")
+	return true
+}
+
+// Returns a connected client: SNMPv3 if credentials are set, the first community
+// answering otherwise (v2c, then v1)
+func (s *EnumScan) snmpConnect(transcript *bytes.Buffer, data stepData) *gosnmp.GoSNMP {
+	if utils.SNMP_V3.User != "" {
+		client, err := snmpV3Client(data.Address, data.Port)
+		if err != nil {
+			s.log().LogError(fmt.Sprintf("[SNMP] %s", err))
+		} else if s.snmpTry(transcript, client) {
+			return client
+		}
+	}
+	for _, community := range s.snmpCommunities() {
+		for _, version := range []gosnmp.SnmpVersion{gosnmp.Version2c, gosnmp.Version1} {
+			client := &gosnmp.GoSNMP{
+				Target:         data.Address,
+				Port:           uint16(data.Port),
+				Community:      community,
+				Version:        version,
+				Timeout:        snmpTimeout,
+				Retries:        1,
+				MaxRepetitions: 25,
+			}
+			if s.Status == model.CANCELLED {
+				return nil
+			}
+			if s.snmpTry(transcript, client) {
+				return client
+			}
+		}
+	}
+	return nil
+}
+
+// Walk a subtree (GETBULK but with v1), the values are recorded in the transcript
+func (s *EnumScan) snmpWalk(transcript *bytes.Buffer, client *gosnmp.GoSNMP, oid string) []gosnmp.SnmpPDU {
+	if err := s.probeAllowed(); err != nil {
+		fmt.Fprintf(transcript, "%s: %s\n", oid, err)
+		return nil
+	}
+	var pdus []gosnmp.SnmpPDU
+	var err error
+	start := time.Now()
+	if client.Version == gosnmp.Version1 {
+		pdus, err = client.WalkAll(oid)
+	} else {
+		pdus, err = client.BulkWalkAll(oid)
+	}
+	address := net.JoinHostPort(client.Target, strconv.Itoa(int(client.Port)))
+	utils.AuditProbe(s.job(), []string{"SNMP", address, snmpVersionName(client.Version), "walk", oid}, start, time.Now(), err)
+	if err != nil {
+		fmt.Fprintf(transcript, "%s: %s\n", oid, err)
+	}
+	for _, pdu := range pdus {
+		fmt.Fprintf(transcript, "%s = %s\n", pdu.Name, snmpString(pdu))
+	}
+	return pdus
+}
+
+// Printable value of a variable
+func snmpString(pdu gosnmp.SnmpPDU) string {
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		b := pdu.Value.([]byte)
+		for _, c := range b {
+			if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+				return snmpMAC(b)
+			}
+		}
+		return strings.TrimSpace(string(b))
+	case gosnmp.IPAddress, gosnmp.ObjectIdentifier:
+		return fmt.Sprintf("%v", pdu.Value)
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return ""
+	}
+	return gosnmp.ToBigInt(pdu.Value).String()
+}
+
+func snmpMAC(b []byte) string {
+	parts := []string{}
+	for _, c := range b {
+		parts = append(parts, fmt.Sprintf("%02x", c))
+	}
+	return strings.Join(parts, ":")
+}
+
+// Index of a variable in its table (e.g., "1234" for hrSWRunName.1234)
+func snmpIndex(pdu gosnmp.SnmpPDU, oid string) string {
+	return strings.TrimPrefix(pdu.Name, oid+".")
+}
+
+// Map index -> value of a table column
+func (s *EnumScan) snmpColumn(transcript *bytes.Buffer, client *gosnmp.GoSNMP, oid string) map[string]string {
+	res := map[string]string{}
+	for _, pdu := range s.snmpWalk(transcript, client, oid) {
+		res[snmpIndex(pdu, oid)] = snmpString(pdu)
+	}
+	return res
+}
+
+// ---------------------------------------------------------------------------------------
+// Inventory
+// ---------------------------------------------------------------------------------------
+// Native SNMP inventory: system information (details), running processes, installed
+// software, user accounts, listening ports and interfaces (stored on the host)
+func probeSNMP(s *EnumScan, data stepData) (string, error) {
+	var transcript bytes.Buffer
+	db := utils.Config.DB
+	client := s.snmpConnect(&transcript, data)
+	if client == nil {
+		s.log().LogDebug(fmt.Sprintf("[SNMP] No SNMP access on %s:%d", data.Address, data.Port))
+		return transcript.String(), nil
+	}
+	defer client.Conn.Close()
+
+	version := snmpVersionName(client.Version)
+	model.AddDetail(db, s.Target, data.Port, "SNMP", SNMP_VERSION, version)
+	if client.Version != gosnmp.Version3 {
+		model.AddDetail(db, s.Target, data.Port, "SNMP", SNMP_COMMUNITY, client.Community)
+		model.AddFinding(db, s.Target, data.Port, "SNMP", model.SEVERITY_MEDIUM,
+			"SNMP readable with a community string", fmt.Sprintf("%s (%s)", client.Community, version))
+	}
+
+	// System
+	system := []string{oidSysDescr, oidSysContact, oidSysName, oidSysLocation, oidSystemProcesses}
+	start := time.Now()
+	res, err := client.Get(system)
+	utils.AuditProbe(s.job(), append([]string{"SNMP", net.JoinHostPort(data.Address, strconv.Itoa(data.Port)), version, "get"}, system...), start, time.Now(), err)
+	if err == nil {
+		keys := map[string]string{oidSysDescr: SNMP_SYSDESCR, oidSysContact: SNMP_CONTACT, oidSysName: SNMP_SYSNAME, oidSysLocation: SNMP_LOCATION, oidSystemProcesses: SNMP_PROCESSES}
+		for _, pdu := range res.Variables {
+			fmt.Fprintf(&transcript, "%s = %s\n", pdu.Name, snmpString(pdu))
+			if v := snmpString(pdu); v != "" {
+				model.AddDetail(db, s.Target, data.Port, "SNMP", keys[pdu.Name], v)
 			}
 		}
 	}
+
+	// Processes
+	names := s.snmpColumn(&transcript, client, oidRunName)
+	paths := s.snmpColumn(&transcript, client, oidRunPath)
+	params := s.snmpColumn(&transcript, client, oidRunParameters)
+	for index, name := range names {
+		pid, _ := strconv.Atoi(index)
+		model.AddProcess(db, s.Target, pid, name, paths[index], params[index])
+	}
+
+	// Storage (transcript only)
+	s.snmpWalk(&transcript, client, oidStorageDescr)
+	s.snmpWalk(&transcript, client, oidStorageUnits)
+
+	// Installed software
+	software := s.snmpColumn(&transcript, client, oidInstalledName)
+	for _, name := range software {
+		if name != "" {
+			model.AddSoftware(db, s.Target, name)
+		}
+	}
+
+	// User accounts (LAN Manager MIB, Windows)
+	users := 0
+	for _, pdu := range s.snmpWalk(&transcript, client, oidLanManUsers) {
+		if name := snmpString(pdu); name != "" {
+			model.AddDomainUser(db, s.Target, "", name, 0, "", "")
+			users++
+		}
+	}
+
+	// Listening ports: tcpConnState is indexed by local address/port and remote address/port,
+	// udpLocalPort by local address/port
+	listeners := 0
+	for _, pdu := range s.snmpWalk(&transcript, client, oidTCPConnState) {
+		parts := strings.Split(snmpIndex(pdu, oidTCPConnState), ".")
+		if len(parts) != 10 || gosnmp.ToBigInt(pdu.Value).Int64() != tcpStateListen {
+			continue
+		}
+		port, _ := strconv.Atoi(parts[4])
+		model.AddListener(db, s.Target, "tcp", strings.Join(parts[:4], "."), port)
+		listeners++
+	}
+	for _, pdu := range s.snmpWalk(&transcript, client, oidUDPLocalPort) {
+		parts := strings.Split(snmpIndex(pdu, oidUDPLocalPort), ".")
+		if len(parts) != 5 {
+			continue
+		}
+		port, _ := strconv.Atoi(parts[4])
+		model.AddListener(db, s.Target, "udp", strings.Join(parts[:4], "."), port)
+		listeners++
+	}
+
+	// Interfaces, with their IPv4 addresses
+	descrs := s.snmpColumn(&transcript, client, oidIfDescr)
+	macs := s.snmpColumn(&transcript, client, oidIfPhysAddress)
+	masks := s.snmpColumn(&transcript, client, oidIPAdEntNetMask)
+	addresses := map[string][]string{}
+	for ip, index := range s.snmpColumn(&transcript, client, oidIPAdEntIfIndex) {
+		cidr := ip
+		if mask := net.ParseIP(masks[ip]).To4(); mask != nil {
+			ones, _ := net.IPv4Mask(mask[0], mask[1], mask[2], mask[3]).Size()
+			cidr = fmt.Sprintf("%s/%d", ip, ones)
+		}
+		addresses[index] = append(addresses[index], cidr)
+	}
+	for index, descr := range descrs {
+		i, _ := strconv.Atoi(index)
+		model.AddInterface(db, s.Target, i, descr, macs[index], strings.Join(addresses[index], ","))
+	}
+
+	s.log().LogNotify(fmt.Sprintf("[SNMP] %s:%d (%s): %d processes, %d software, %d users, %d listeners, %d interfaces",
+		data.Address, data.Port, version, len(names), len(software), users, listeners, len(descrs)))
+	return transcript.String(), nil
 }
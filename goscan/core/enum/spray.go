@@ -0,0 +1,260 @@
+package enum
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// CREDENTIAL SPRAY
+// ---------------------------------------------------------------------------------------
+// Known credentials are tried against the matching services of the hosts in scope,
+// one hydra attempt per account (username, service, host)
+const (
+	SPRAY = "SPRAY"
+
+	// Assumed when the lockout threshold of a host/domain is unknown
+	sprayDefaultThreshold = 3
+)
+
+// Services credentials are tried against, by hydra module
+var sprayServices = []struct {
+	Module string
+	Match  RecipeMatch
+}{
+	{"ssh", RecipeMatch{Ports: []int{22}, serviceRegex: regexp.MustCompile(`(?i)^ssh$`)}},
+	{"ftp", RecipeMatch{Ports: []int{21}, serviceRegex: regexp.MustCompile(`(?i)^ftp$`)}},
+	{"smb", RecipeMatch{Ports: []int{139, 445}, serviceRegex: regexp.MustCompile(`(?i)microsoft-ds|netbios-ssn`)}},
+	{"mssql", RecipeMatch{Ports: []int{1433}, serviceRegex: regexp.MustCompile(`(?i)ms-sql`)}},
+	{"mysql", RecipeMatch{Ports: []int{3306}, serviceRegex: regexp.MustCompile(`(?i)^mysql$`)}},
+}
+
+// Credential decrypted from the store
+type sprayAccount struct {
+	Username string
+	Secret   string
+}
+
+// SMB accounts are usually domain accounts: lockout checks and attempts on the hosts
+// of a domain are serialized across jobs
+var sprayLock sync.Mutex
+
+// Returns the credentials of the store, one per username and secret (verified ones first):
+// the same account can be tried with the passwords found on different hosts, within the
+// limit of one attempt per account per host
+func sprayAccounts() ([]sprayAccount, error) {
+	accounts := []sprayAccount{}
+	seen := map[sprayAccount]bool{}
+	creds := model.GetAllCredentials(utils.Config.DB)
+	for _, verified := range []bool{true, false} {
+		for _, c := range creds {
+			if c.Verified != verified || c.Secret == "" {
+				continue
+			}
+			secret, err := utils.Decrypt(c.Secret)
+			if err != nil {
+				return nil, err
+			}
+			account := sprayAccount{Username: c.Username, Secret: secret}
+			if seen[account] {
+				continue
+			}
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+// Lockout threshold for the accounts of a host (0 if there is no lockout policy): the SMB
+// password policy of the host, or of any host of its domain
+func lockoutThreshold(hosts []model.Host) int {
+	for _, h := range hosts {
+		for _, v := range h.GetDetailValues(utils.Config.DB, SMB_LOCKOUT_THRESHOLD) {
+			if v == "None" {
+				return 0
+			}
+			if n, err := strconv.Atoi(v); err == nil {
+				return n
+			}
+		}
+	}
+	return sprayDefaultThreshold
+}
+
+// Whether one more failed attempt for the account keeps it below the lockout threshold
+func (s *EnumScan) belowLockout(username string) bool {
+	hosts := []model.Host{*s.Target}
+	if s.Target.Domain != "" {
+		hosts = model.GetHostByDomain(utils.Config.DB, s.Target.Domain)
+	}
+	threshold := lockoutThreshold(hosts)
+	if threshold == 0 {
+		return true
+	}
+	return model.CountFailedSprayAttempts(utils.Config.DB, hosts, "smb", username)+1 < threshold
+}
+
+// One hydra attempt; the credential is handed over in a file, so that the secret
+// never shows up in the audit log. hydra's output (with the password in cleartext) goes
+// to a temporary file too: the outcome is recorded in the DB, and the secret in the vault
+func (s *EnumScan) sprayAttempt(port int, module string, account sprayAccount) bool {
+	db := utils.Config.DB
+
+	f, err := ioutil.TempFile("", "goscan-spray-")
+	if err != nil {
+		s.log().LogError(fmt.Sprintf("[SPRAY] Cannot create credentials file: %s", err))
+		return false
+	}
+	defer os.Remove(f.Name())
+	if s.Polite != "DRY" {
+		fmt.Fprintf(f, "%s:%s\n", account.Username, account.Secret)
+	}
+	f.Close()
+	out, err := ioutil.TempFile("", "goscan-spray-out-")
+	if err != nil {
+		s.log().LogError(fmt.Sprintf("[SPRAY] Cannot create output file: %s", err))
+		return false
+	}
+	output := out.Name()
+	defer os.Remove(output)
+	out.Close()
+
+	cmd := fmt.Sprintf("hydra -C %s -t 1 -f -o %s -s %d %s %s", f.Name(), output, port, s.Target.Address, module)
+	// Nothing has been sent in a dry run, nor if the job was cancelled. Any other error
+	// still counts as an attempt: hydra may have tried the password before failing
+	if _, err := s.runCmd(cmd); err == utils.ErrCancelled || s.Polite == "DRY" {
+		return false
+	}
+
+	success := false
+	for _, line := range readLines(output) {
+		if m := hydraLogin.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil && m[4] == account.Username {
+			success = true
+		}
+	}
+	model.AddSprayAttempt(db, s.Target, port, module, account.Username, success)
+	if success {
+		s.addCredential(port, module, account.Username, account.Secret, "spray", true)
+	}
+	return success
+}
+
+func (s *EnumScan) runSpray(accounts []sprayAccount) {
+	s.preScan()
+	db := utils.Config.DB
+	attempts, successes, skipped := 0, 0, 0
+
+	for _, port := range s.Target.GetPorts(db) {
+		if port.Status != "open" {
+			continue
+		}
+		service := port.GetService(db)
+		for _, srv := range sprayServices {
+			if !srv.Match.matches(port, service) {
+				continue
+			}
+			for _, account := range accounts {
+				if s.Status == model.CANCELLED {
+					return
+				}
+				// One attempt per account, and none for the credentials already known
+				if s.Target.HasSprayAttempt(db, srv.Module, account.Username) || knownCredential(s.Target, port.Number, account.Username) {
+					continue
+				}
+				if srv.Module == "smb" {
+					sprayLock.Lock()
+					if !s.belowLockout(account.Username) {
+						sprayLock.Unlock()
+						s.log().LogWarning(fmt.Sprintf("[SPRAY] %s:%d: %s skipped (lockout threshold)", s.Target.Address, port.Number, account.Username))
+						skipped++
+						continue
+					}
+				}
+				attempts++
+				if s.sprayAttempt(port.Number, srv.Module, account) {
+					successes++
+				}
+				if srv.Module == "smb" {
+					sprayLock.Unlock()
+				}
+			}
+		}
+	}
+
+	if s.Polite != "DRY" {
+		s.log().LogNotify(fmt.Sprintf("[SPRAY] %s: %d attempts, %d successes, %d skipped (lockout)", s.Target.Address, attempts, successes, skipped))
+	}
+	s.postScan()
+}
+
+func knownCredential(h *model.Host, port int, username string) bool {
+	for _, c := range h.GetCredentials(utils.Config.DB) {
+		if c.Port == port && c.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------------------
+// SPRAY LAUNCHER
+// ---------------------------------------------------------------------------------------
+func ScanSpray(polite, target string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot spray credentials")
+		return
+	}
+	if len(model.GetAllTargets(utils.Config.DB)) == 0 {
+		utils.Config.Log.LogError("No targets imported: the scope is undefined (load target SINGLE/MULTI)")
+		return
+	}
+	if !utils.VaultUnlocked() {
+		utils.Config.Log.LogError(utils.ErrVaultLocked.Error())
+		return
+	}
+	// Failed hydra runs count as attempts
+	if polite != "DRY" && !utils.IsCommandAvailable("hydra") {
+		utils.Config.Log.LogError("Hydra is not installed or not in PATH")
+		return
+	}
+	accounts, err := sprayAccounts()
+	if err != nil {
+		utils.Config.Log.LogError(fmt.Sprintf("Cannot decrypt the credentials: %s", err))
+		return
+	}
+	if len(accounts) == 0 {
+		utils.Config.Log.LogInfo("No credentials to spray yet")
+		return
+	}
+	utils.Config.Log.LogInfo(fmt.Sprintf("Spraying %d credentials", len(accounts)))
+	origin := utils.CurrentOrigin()
+
+	for _, h := range model.GetAllHosts(utils.Config.DB) {
+		if target != "ALL" && target != h.Address {
+			continue
+		}
+		if !model.InScope(utils.Config.DB, h.Address) {
+			utils.Config.Log.LogWarning(fmt.Sprintf("[SPRAY] %s skipped (out of scope)", h.Address))
+			continue
+		}
+		temp := h
+		go workerSpray(&temp, polite, accounts, origin)
+	}
+}
+
+func workerSpray(h *model.Host, polite string, accounts []sprayAccount, origin string) {
+	s := NewEnumScan(h, SPRAY, polite)
+	s.Origin = origin
+	EnumList = append(EnumList, s)
+
+	s.runSpray(accounts)
+}
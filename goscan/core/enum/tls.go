@@ -0,0 +1,278 @@
+package enum
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// TLS / SSL
+// ---------------------------------------------------------------------------------------
+const (
+	TLS_VERSION     = "tls_version"
+	TLS_WEAK_CIPHER = "tls_weak_cipher"
+	CERT_KEY        = "cert_key"
+
+	tlsMinKeySize = 2048
+)
+
+// Protocol versions supported by crypto/tls (SSLv2/SSLv3 are only reported by ssl-enum-ciphers)
+var tlsVersions = []struct {
+	ID   uint16
+	Name string
+}{
+	{tls.VersionTLS10, "TLSv1.0"},
+	{tls.VersionTLS11, "TLSv1.1"},
+	{tls.VersionTLS12, "TLSv1.2"},
+	{tls.VersionTLS13, "TLSv1.3"},
+}
+
+// Handshake with a single protocol version offering the given cipher suites (crypto/tls
+// defaults if none), audited and recorded in the transcript
+func (s *EnumScan) tlsHandshake(transcript *bytes.Buffer, address string, version uint16, suites []uint16) (*tls.ConnectionState, error) {
+	if err := s.probeAllowed(); err != nil {
+		fmt.Fprintf(transcript, "%s %s %s -> %s\n", address, tlsVersionName(version), cipherNames(suites), err)
+		return nil, err
+	}
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         version,
+		MaxVersion:         version,
+		CipherSuites:       suites,
+	}
+	// Send SNI only when targeting a hostname
+	if host, _, _ := net.SplitHostPort(address); net.ParseIP(host) == nil {
+		config.ServerName = host
+	}
+
+	start := time.Now()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: probeTimeout}, "tcp", address, config)
+	utils.AuditProbe(s.job(), []string{"TLS", address, tlsVersionName(version), cipherNames(suites)}, start, time.Now(), err)
+	if err != nil {
+		fmt.Fprintf(transcript, "%s %s %s -> %s\n", address, tlsVersionName(version), cipherNames(suites), err)
+		return nil, err
+	}
+	defer conn.Close()
+	state := conn.ConnectionState()
+	fmt.Fprintf(transcript, "%s %s %s -> %s\n", address, tlsVersionName(version), cipherNames(suites), tls.CipherSuiteName(state.CipherSuite))
+	return &state, nil
+}
+
+func tlsVersionName(version uint16) string {
+	for _, v := range tlsVersions {
+		if v.ID == version {
+			return v.Name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+func cipherNames(suites []uint16) string {
+	if len(suites) == 0 {
+		return "(default cipher suites)"
+	}
+	if len(suites) > 1 {
+		return fmt.Sprintf("(%d cipher suites)", len(suites))
+	}
+	names := []string{}
+	for _, c := range suites {
+		names = append(names, tls.CipherSuiteName(c))
+	}
+	return strings.Join(names, ",")
+}
+
+// Algorithm and size of the public key of a certificate (e.g., "RSA 2048")
+func certificateKey(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return cert.PublicKeyAlgorithm.String(), 0
+}
+
+// True if the certificate is signed by its own key. CheckSignatureFrom is not used: it
+// rejects issuers which are not CAs, and most self-signed leaf certificates are CA:FALSE
+func selfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// Store the certificate of the service, flag expired/self-signed/weak ones,
+// and add its SAN hostnames to the host
+func (s *EnumScan) recordCertificate(port int, cert *x509.Certificate) {
+	db := utils.Config.DB
+	model.AddDetail(db, s.Target, port, "TLS", CERT_SUBJECT, cert.Subject.String())
+	model.AddDetail(db, s.Target, port, "TLS", CERT_ISSUER, cert.Issuer.String())
+	model.AddDetail(db, s.Target, port, "TLS", CERT_NOT_AFTER, cert.NotAfter.UTC().Format("2006-01-02T15:04:05"))
+	if len(cert.DNSNames) > 0 {
+		model.AddDetail(db, s.Target, port, "TLS", CERT_SAN, strings.Join(cert.DNSNames, ", "))
+	}
+	algorithm, size := certificateKey(cert)
+	model.AddDetail(db, s.Target, port, "TLS", CERT_KEY, strings.TrimSpace(fmt.Sprintf("%s %d", algorithm, size)))
+
+	now := time.Now()
+	if now.After(cert.NotAfter) {
+		model.AddFinding(db, s.Target, port, "TLS", model.SEVERITY_MEDIUM,
+			"Expired TLS certificate", fmt.Sprintf("%s expired on %s", cert.Subject, cert.NotAfter.UTC().Format("2006-01-02")))
+	} else if now.Before(cert.NotBefore) {
+		model.AddFinding(db, s.Target, port, "TLS", model.SEVERITY_LOW,
+			"TLS certificate not yet valid", fmt.Sprintf("%s valid from %s", cert.Subject, cert.NotBefore.UTC().Format("2006-01-02")))
+	}
+	if selfSigned(cert) {
+		model.AddFinding(db, s.Target, port, "TLS", model.SEVERITY_MEDIUM,
+			"Self-signed TLS certificate", cert.Subject.String())
+	}
+	if algorithm == "RSA" && size < tlsMinKeySize {
+		model.AddFinding(db, s.Target, port, "TLS", model.SEVERITY_MEDIUM,
+			"Weak TLS certificate key", fmt.Sprintf("RSA %d bits", size))
+	}
+	switch cert.SignatureAlgorithm {
+	case x509.MD5WithRSA, x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		model.AddFinding(db, s.Target, port, "TLS", model.SEVERITY_LOW,
+			"Weak TLS certificate signature", cert.SignatureAlgorithm.String())
+	}
+
+	// Wildcards are not hostnames
+	names := []string{}
+	for _, n := range cert.DNSNames {
+		if !strings.HasPrefix(n, "*") {
+			names = append(names, n)
+		}
+	}
+	s.Target.AddHostnames(db, names)
+}
+
+// Native TLS inventory: supported protocol versions, certificate, and weak cipher suites
+// (the ones crypto/tls considers insecure: RC4, 3DES, CBC with SHA-256)
+func probeTLS(s *EnumScan, data stepData) (string, error) {
+	var transcript bytes.Buffer
+	address := net.JoinHostPort(data.Address, strconv.Itoa(data.Port))
+	db := utils.Config.DB
+
+	// Protocol versions (every suite is offered up to TLSv1.2)
+	all := []uint16{}
+	for _, c := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		all = append(all, c.ID)
+	}
+	supported := []uint16{}
+	var cert *x509.Certificate
+	for _, v := range tlsVersions {
+		suites := all
+		if v.ID == tls.VersionTLS13 {
+			suites = nil
+		}
+		state, err := s.tlsHandshake(&transcript, address, v.ID, suites)
+		if err != nil {
+			continue
+		}
+		supported = append(supported, v.ID)
+		model.AddDetail(db, s.Target, data.Port, "TLS", TLS_VERSION, v.Name)
+		if len(state.PeerCertificates) > 0 {
+			cert = state.PeerCertificates[0]
+		}
+	}
+	if len(supported) == 0 {
+		s.log().LogDebug(fmt.Sprintf("[TLS] No TLS handshake on %s", address))
+		return transcript.String(), nil
+	}
+
+	for _, v := range supported {
+		if v == tls.VersionTLS10 || v == tls.VersionTLS11 {
+			model.AddFinding(db, s.Target, data.Port, "TLS", model.SEVERITY_LOW,
+				"Deprecated TLS protocol enabled", tlsVersionName(v))
+		}
+	}
+	if cert != nil {
+		s.recordCertificate(data.Port, cert)
+	}
+
+	// Weak cipher suites, offered one at a time
+	weak := []string{}
+	for _, c := range tls.InsecureCipherSuites() {
+		for _, v := range supported {
+			if v == tls.VersionTLS13 || !supportsVersion(c, v) {
+				continue
+			}
+			if _, err := s.tlsHandshake(&transcript, address, v, []uint16{c.ID}); err == nil {
+				weak = append(weak, c.Name)
+				model.AddDetail(db, s.Target, data.Port, "TLS", TLS_WEAK_CIPHER, c.Name)
+				break
+			}
+		}
+	}
+	if len(weak) > 0 {
+		model.AddFinding(db, s.Target, data.Port, "TLS", model.SEVERITY_MEDIUM,
+			"Weak TLS cipher suites", strings.Join(weak, ", "))
+	}
+
+	versions := []string{}
+	for _, v := range supported {
+		versions = append(versions, tlsVersionName(v))
+	}
+	s.log().LogNotify(fmt.Sprintf("[TLS] %s: %s, %d weak cipher suites", address, strings.Join(versions, " "), len(weak)))
+	return transcript.String(), nil
+}
+
+func supportsVersion(c *tls.CipherSuite, version uint16) bool {
+	for _, v := range c.SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	sslEnumVersion = regexp.MustCompile(`^\s*(SSLv2|SSLv3|TLSv1\.[0-3]):\s*$`)
+	sslEnumCipher  = regexp.MustCompile(`^\s*(\S+) \(.*\) - ([A-F])\s*$`)
+)
+
+// Parse ssl-enum-ciphers: protocol versions, and cipher suites graded C or worse
+func parseTLS(s *EnumScan, data stepData, output string) {
+	db := utils.Config.DB
+	for _, sc := range nmapScripts(output) {
+		if sc.ID != "ssl-enum-ciphers" {
+			continue
+		}
+		weak := map[string]bool{}
+		list := []string{}
+		for _, line := range strings.Split(sc.Output, "\n") {
+			if m := sslEnumVersion.FindStringSubmatch(line); m != nil {
+				model.AddDetail(db, s.Target, data.Port, "TLS", TLS_VERSION, m[1])
+				switch m[1] {
+				case "SSLv2", "SSLv3":
+					model.AddFinding(db, s.Target, data.Port, "TLS", model.SEVERITY_HIGH, "Obsolete SSL protocol enabled", m[1])
+				case "TLSv1.0", "TLSv1.1":
+					model.AddFinding(db, s.Target, data.Port, "TLS", model.SEVERITY_LOW, "Deprecated TLS protocol enabled", m[1])
+				}
+				continue
+			}
+			if m := sslEnumCipher.FindStringSubmatch(line); m != nil && m[2] >= "C" && !weak[m[1]] {
+				weak[m[1]] = true
+				list = append(list, m[1])
+				model.AddDetail(db, s.Target, data.Port, "TLS", TLS_WEAK_CIPHER, m[1])
+			}
+		}
+		if len(list) > 0 {
+			model.AddFinding(db, s.Target, data.Port, "TLS", model.SEVERITY_MEDIUM,
+				"Weak TLS cipher suites", strings.Join(list, ", "))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package enum
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// Certificate for the name, signed by the parent (self-signed if nil)
+func testCertificate(t *testing.T, name string, ca bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate a key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  ca,
+	}
+	if ca {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("cannot create the certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("cannot parse the certificate: %s", err)
+	}
+	return cert, key
+}
+
+func TestSelfSigned(t *testing.T) {
+	leaf, _ := testCertificate(t, "appliance.local", false, nil, nil)
+	root, rootKey := testCertificate(t, "Root CA", true, nil, nil)
+	issued, _ := testCertificate(t, "www.example.com", false, root, rootKey)
+	// Same subject as its issuer, but signed by another key
+	impostor, _ := testCertificate(t, "Root CA", false, root, rootKey)
+
+	cases := []struct {
+		name string
+		cert *x509.Certificate
+		want bool
+	}{
+		{"self-signed leaf (CA:FALSE)", leaf, true},
+		{"self-signed CA", root, true},
+		{"issued by a CA", issued, false},
+		{"issuer name only", impostor, false},
+	}
+	for _, c := range cases {
+		if got := selfSigned(c.cert); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
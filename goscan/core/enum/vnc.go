@@ -0,0 +1,74 @@
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// VNC / X11
+// ---------------------------------------------------------------------------------------
+const (
+	VNC_PROTOCOL      = "vnc_protocol"
+	VNC_SECURITY_TYPE = "vnc_security_type"
+)
+
+// Parse vnc-info and realvnc-auth-bypass: protocol version and security types are
+// stored as details, servers accepting connections without authentication as findings
+func parseVNC(s *EnumScan, data stepData, output string) {
+	for _, sc := range nmapScripts(output) {
+		switch sc.ID {
+		case "vnc-info":
+			inTypes := false
+			for _, line := range strings.Split(sc.Output, "\n") {
+				trimmed := strings.TrimSpace(line)
+				if trimmed == "" {
+					continue
+				}
+				if strings.HasPrefix(trimmed, "Protocol version:") {
+					model.AddDetail(utils.Config.DB, s.Target, data.Port, "VNC", VNC_PROTOCOL, strings.TrimSpace(strings.TrimPrefix(trimmed, "Protocol version:")))
+					continue
+				}
+				if strings.HasPrefix(trimmed, "Security types:") {
+					inTypes = true
+					continue
+				}
+				// Security types are listed (more indented) below their header
+				if inTypes && strings.HasSuffix(trimmed, ")") && !strings.HasSuffix(trimmed, ":") {
+					model.AddDetail(utils.Config.DB, s.Target, data.Port, "VNC", VNC_SECURITY_TYPE, trimmed)
+					if strings.HasPrefix(trimmed, "None (") {
+						model.AddFinding(utils.Config.DB, s.Target, data.Port, "VNC", model.SEVERITY_HIGH,
+							"VNC without authentication", "Security type None is offered")
+						s.log().LogNotify(fmt.Sprintf("[VNC] No authentication required on %s:%d", s.Target.Address, data.Port))
+					}
+					continue
+				}
+				inTypes = false
+				if strings.Contains(trimmed, "does not require authentication") {
+					model.AddFinding(utils.Config.DB, s.Target, data.Port, "VNC", model.SEVERITY_HIGH,
+						"VNC without authentication", trimmed)
+				}
+			}
+		case "realvnc-auth-bypass":
+			if strings.Contains(sc.Output, "VULNERABLE") && !strings.Contains(sc.Output, "NOT VULNERABLE") {
+				model.AddFinding(utils.Config.DB, s.Target, data.Port, "VNC", model.SEVERITY_CRITICAL,
+					"RealVNC authentication bypass", "CVE-2006-2369")
+				s.log().LogNotify(fmt.Sprintf("[VNC] RealVNC authentication bypass on %s:%d", s.Target.Address, data.Port))
+			}
+		}
+	}
+}
+
+// Parse x11-access: an X server granting access is a finding
+func parseX11(s *EnumScan, data stepData, output string) {
+	for _, sc := range nmapScripts(output) {
+		if sc.ID == "x11-access" && strings.Contains(sc.Output, "access is granted") {
+			model.AddFinding(utils.Config.DB, s.Target, data.Port, "X11", model.SEVERITY_HIGH,
+				"X11 server allows unauthenticated access", strings.TrimSpace(sc.Output))
+			s.log().LogNotify(fmt.Sprintf("[X11] Access granted on %s:%d", s.Target.Address, data.Port))
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package enum
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// WEB FINGERPRINTING
+// ---------------------------------------------------------------------------------------
+const webMaxRedirects = 5
+
+// Headers expected on a hardened web application (HSTS only applies to HTTPS)
+var securityHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// Technology signature: a regex on a response header, or on the body if no header is given
+type webTechnology struct {
+	Name    string
+	Header  string
+	Pattern *regexp.Regexp
+}
+
+var webTechnologies = []webTechnology{
+	{"Apache", "Server", regexp.MustCompile(`(?i)apache`)},
+	{"nginx", "Server", regexp.MustCompile(`(?i)nginx`)},
+	{"IIS", "Server", regexp.MustCompile(`(?i)microsoft-iis`)},
+	{"lighttpd", "Server", regexp.MustCompile(`(?i)lighttpd`)},
+	{"Jetty", "Server", regexp.MustCompile(`(?i)jetty`)},
+	{"PHP", "X-Powered-By", regexp.MustCompile(`(?i)php`)},
+	{"ASP.NET", "X-Powered-By", regexp.MustCompile(`(?i)asp\.net`)},
+	{"ASP.NET", "X-AspNet-Version", regexp.MustCompile(`.`)},
+	{"Express", "X-Powered-By", regexp.MustCompile(`(?i)express`)},
+	{"PHP", "Set-Cookie", regexp.MustCompile(`PHPSESSID`)},
+	{"Java", "Set-Cookie", regexp.MustCompile(`JSESSIONID`)},
+	{"ASP.NET", "Set-Cookie", regexp.MustCompile(`ASP\.NET_SessionId`)},
+	{"Drupal", "X-Generator", regexp.MustCompile(`(?i)drupal`)},
+	{"Jenkins", "X-Jenkins", regexp.MustCompile(`.`)},
+	{"WordPress", "", regexp.MustCompile(`/wp-(?:content|includes)/`)},
+	{"Joomla", "", regexp.MustCompile(`(?i)/media/(?:jui|system)/js/`)},
+	{"Drupal", "", regexp.MustCompile(`Drupal\.settings|/sites/default/files/`)},
+	{"Tomcat", "", regexp.MustCompile(`Apache Tomcat`)},
+	{"GitLab", "", regexp.MustCompile(`content="GitLab"`)},
+	{"Grafana", "", regexp.MustCompile(`grafana-app|window\.grafanaBootData`)},
+	{"Outlook Web App", "", regexp.MustCompile(`/owa/auth/`)},
+	{"jQuery", "", regexp.MustCompile(`jquery[.-][0-9.]*(?:min\.)?js`)},
+	{"Bootstrap", "", regexp.MustCompile(`bootstrap(?:\.min)?\.(?:css|js)`)},
+}
+
+var (
+	webTitle     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	webGenerator = regexp.MustCompile(`(?is)<meta[^>]+name=["']generator["'][^>]+content=["']([^"']+)["']`)
+	webIconLink  = regexp.MustCompile(`(?is)<link[^>]+rel=["']?(?:shortcut )?icon["']?[^>]*>`)
+	webHref      = regexp.MustCompile(`(?is)href=["']?([^"' >]+)`)
+)
+
+// MurmurHash3 (x86, 32-bit) with seed 0, as a signed integer
+func mmh3(data []byte) int32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= c1
+		k = k<<15 | k>>17
+		k *= c2
+		h ^= k
+		h = h<<13 | h>>19
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	switch len(data) - n {
+	case 3:
+		k ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[n])
+		k *= c1
+		k = k<<15 | k>>17
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return int32(h)
+}
+
+// Favicon hash as computed by Shodan: mmh3 of the base64 encoding, wrapped every 76 characters
+func faviconHash(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var wrapped bytes.Buffer
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		wrapped.WriteString(encoded[i:end])
+		wrapped.WriteByte('\n')
+	}
+	return strconv.Itoa(int(mmh3(wrapped.Bytes())))
+}
+
+// Technologies disclosed by the headers and the body of a response
+func detectTechnologies(resp *probeResponse) []string {
+	found := map[string]bool{}
+	for _, t := range webTechnologies {
+		if t.Header == "" {
+			found[t.Name] = found[t.Name] || t.Pattern.Match(resp.Body)
+		} else {
+			found[t.Name] = found[t.Name] || t.Pattern.MatchString(strings.Join(resp.Header.Values(t.Header), "\n"))
+		}
+	}
+	if m := webGenerator.FindSubmatch(resp.Body); m != nil {
+		found[html.UnescapeString(string(m[1]))] = true
+	}
+	technologies := []string{}
+	for name, ok := range found {
+		if !ok {
+			continue
+		}
+		// Prefer the versioned name from the generator (e.g., "WordPress 5.8" over "WordPress")
+		versioned := false
+		for other, ok := range found {
+			versioned = versioned || (ok && strings.HasPrefix(other, name+" "))
+		}
+		if !versioned {
+			technologies = append(technologies, name)
+		}
+	}
+	sort.Strings(technologies)
+	return technologies
+}
+
+// Store the certificate presented by the web server
+func certificateInfo(w *model.Web, state *tls.ConnectionState) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+	cert := state.PeerCertificates[0]
+	w.CertSubject = cert.Subject.String()
+	w.CertIssuer = cert.Issuer.String()
+	w.CertSANs = strings.Join(cert.DNSNames, ", ")
+	w.CertNotAfter = cert.NotAfter.UTC().Format("2006-01-02T15:04:05")
+}
+
+// Whether a URL points to the target (address or known hostnames) or to another
+// in-scope address
+func (s *EnumScan) inScopeURL(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == strings.ToLower(s.Target.Address) {
+		return true
+	}
+	for _, name := range strings.Split(s.Target.Hostnames, ",") {
+		if host == name {
+			return true
+		}
+	}
+	return utils.IsDBAvailable() && model.InScope(utils.Config.DB, host)
+}
+
+// Fetch the root page of the web service (following redirects),
+// and record status, title, server, security headers, favicon hash, certificate and technologies
+func probeHTTP(s *EnumScan, data stepData) (string, error) {
+	var transcript bytes.Buffer
+	target := fmt.Sprintf("%s://%s/", detectScheme(data.Address, data.Port), net.JoinHostPort(data.Address, strconv.Itoa(data.Port)))
+
+	// Follow the redirects (up to webMaxRedirects)
+	chain := []string{target}
+	resp, err := s.httpRequest(&transcript, "GET", target, nil)
+	for i := 0; err == nil && i < webMaxRedirects; i++ {
+		location := resp.Header.Get("Location")
+		if resp.Status < 300 || resp.Status >= 400 || location == "" {
+			break
+		}
+		base, _ := url.Parse(target)
+		next, perr := base.Parse(location)
+		if perr != nil {
+			break
+		}
+		// Redirects outside of the engagement (SSO, CDN, third parties) are only recorded
+		if !s.inScopeURL(next) {
+			chain = append(chain, fmt.Sprintf("%s (out of scope, not followed)", next))
+			break
+		}
+		target = next.String()
+		chain = append(chain, target)
+		resp, err = s.httpRequest(&transcript, "GET", target, nil)
+	}
+	if err != nil {
+		return transcript.String(), err
+	}
+
+	w := &model.Web{
+		URL:        target,
+		StatusCode: resp.Status,
+		Server:     resp.Header.Get("Server"),
+	}
+	if len(chain) > 1 {
+		w.Redirects = strings.Join(chain, " -> ")
+	}
+	if m := webTitle.FindSubmatch(resp.Body); m != nil {
+		w.Title = strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+	}
+
+	// Security headers
+	present, missing := []string{}, []string{}
+	for _, h := range securityHeaders {
+		if resp.Header.Get(h) != "" {
+			present = append(present, h)
+		} else if h != "Strict-Transport-Security" || strings.HasPrefix(target, "https") {
+			missing = append(missing, h)
+		}
+	}
+	w.SecurityHeaders = strings.Join(present, ", ")
+	w.MissingHeaders = strings.Join(missing, ", ")
+
+	w.Technologies = strings.Join(detectTechnologies(resp), ", ")
+	certificateInfo(w, resp.TLS)
+
+	// Favicon: declared in the page, or at the default location
+	icon, _ := url.Parse(target)
+	icon, _ = icon.Parse("/favicon.ico")
+	if link := webIconLink.Find(resp.Body); link != nil {
+		if m := webHref.FindSubmatch(link); m != nil {
+			if u, perr := icon.Parse(html.UnescapeString(string(m[1]))); perr == nil {
+				icon = u
+			}
+		}
+	}
+	// Favicons hosted elsewhere (e.g., on a CDN) are not fetched
+	if s.inScopeURL(icon) {
+		if fav, ferr := s.httpRequest(&transcript, "GET", icon.String(), nil); ferr == nil && fav.Status == http.StatusOK && len(fav.Body) > 0 {
+			w.FaviconHash = faviconHash(fav.Body)
+		}
+	}
+
+	if data.service != nil && data.service.ID != 0 {
+		model.SetWeb(utils.Config.DB, data.service, w)
+	}
+	s.log().LogNotify(fmt.Sprintf("[HTTP] %s [%d] %s", w.URL, w.StatusCode, w.Title))
+	return transcript.String(), nil
+}
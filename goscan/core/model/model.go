@@ -2,7 +2,9 @@ package model
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -54,6 +56,23 @@ func migrateDB(db *gorm.DB) {
 	db.AutoMigrate(&Service{})
 	db.AutoMigrate(&Port{})
 	db.AutoMigrate(&Host{})
+	db.AutoMigrate(&Window{})
+	db.AutoMigrate(&Detail{})
+	db.AutoMigrate(&Finding{})
+	db.AutoMigrate(&Web{})
+	db.AutoMigrate(&WebURL{})
+	db.AutoMigrate(&DomainUser{})
+	db.AutoMigrate(&DomainGroup{})
+	db.AutoMigrate(&GroupMember{})
+	db.AutoMigrate(&Share{})
+	db.AutoMigrate(&Domain{})
+	db.AutoMigrate(&NetBIOSName{})
+	db.AutoMigrate(&Credential{})
+	db.AutoMigrate(&SprayAttempt{})
+	db.AutoMigrate(&Process{})
+	db.AutoMigrate(&Software{})
+	db.AutoMigrate(&Listener{})
+	db.AutoMigrate(&Interface{})
 }
 
 // ---------------------------------------------------------------------------------------
@@ -96,6 +115,20 @@ func GetTargetByStep(db *gorm.DB, step string) []Target {
 	return targets
 }
 
+// An address is in scope if it is (or falls within) one of the imported targets
+func InScope(db *gorm.DB, address string) bool {
+	ip := net.ParseIP(address)
+	for _, t := range GetAllTargets(db) {
+		if t.Address == address {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(t.Address); err == nil && ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // ---------------------------------------------------------------------------------------
 // SERVICE
 // ---------------------------------------------------------------------------------------
@@ -118,20 +151,17 @@ func (s *Service) String() string {
 	return out
 }
 
-// Constructor
+// Constructor (a port has one service: the one of a later scan replaces it, but empty
+// fields do not overwrite the known ones)
 func AddService(db *gorm.DB, name, version, product, osType string, p *Port, pID uint) *Service {
 	lock.Lock()
 	defer lock.Unlock()
 
-	t := &Service{
-		Name:    name,
-		Version: version,
-		Product: product,
-		OsType:  osType,
-		Port:    p,
-		PortID:  pID,
-	}
-	db.Create(t)
+	t := &Service{}
+	db.Where(Service{PortID: pID}).
+		Assign(Service{Name: name, Version: version, Product: product, OsType: osType}).
+		FirstOrCreate(t)
+	t.Port = p
 	return t
 }
 
@@ -181,6 +211,8 @@ func AddPort(db *gorm.DB, number int, protocol, status string, h *Host) (*Port,
 	if err := db.Create(t).Error; err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
 			duplicate = true
+			// Return the existing port
+			db.Where("number = ? AND protocol = ? AND status = ? AND host_id = ?", number, protocol, status, h.ID).First(t)
 		}
 	}
 
@@ -204,13 +236,15 @@ func (p *Port) GetHost(db *gorm.DB) *Host {
 // HOST
 // ---------------------------------------------------------------------------------------
 type Host struct {
-	ID      uint   `gorm:"primary_key"`
-	Address string `gorm:"unique_index:idx_hostname_ip"`
-	Status  string
-	OS      string
-	Info    string
-	Ports   []Port
-	Step    string
+	ID        uint   `gorm:"primary_key"`
+	Address   string `gorm:"unique_index:idx_hostname_ip"`
+	Status    string
+	OS        string
+	Info      string
+	Domain    string
+	Hostnames string
+	Ports     []Port
+	Step      string
 }
 
 // Print to string
@@ -251,6 +285,42 @@ func GetHostByAddress(db *gorm.DB, address string) *Host {
 	return host
 }
 
+func GetHostByDomain(db *gorm.DB, domain string) []Host {
+	hosts := []Host{}
+	db.Where("domain = ?", domain).Find(&hosts)
+	return hosts
+}
+
+// Link the host to an (AD) domain
+func (h *Host) SetDomain(db *gorm.DB, domain string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	h.Domain = domain
+	db.Model(h).Update("domain", domain)
+}
+
+// Add hostnames (e.g., from certificates) to the comma-separated list of the host
+func (h *Host) AddHostnames(db *gorm.DB, names []string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	known := map[string]bool{}
+	list := []string{}
+	for _, n := range append(strings.Split(h.Hostnames, ","), names...) {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n != "" && !known[n] {
+			known[n] = true
+			list = append(list, n)
+		}
+	}
+	sort.Strings(list)
+	if hostnames := strings.Join(list, ","); hostnames != h.Hostnames {
+		h.Hostnames = hostnames
+		db.Model(h).Update("hostnames", hostnames)
+	}
+}
+
 func (h *Host) GetPorts(db *gorm.DB) []Port {
 	if db == nil {
 		return []Port{}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// DOMAIN USER
+// ---------------------------------------------------------------------------------------
+// Account enumerated from a host (e.g., enum4linux over a null session)
+type DomainUser struct {
+	ID          uint   `gorm:"primary_key"`
+	HostID      uint   `gorm:"unique_index:idx_domain_user"`
+	Username    string `gorm:"unique_index:idx_domain_user"`
+	Domain      string
+	RID         int `gorm:"column:rid"`
+	FullName    string
+	Description string
+}
+
+// Print to string
+func (u *DomainUser) String() string {
+	if u.Domain != "" {
+		return fmt.Sprintf("%s\\%s", u.Domain, u.Username)
+	}
+	return u.Username
+}
+
+// Constructor (fields of an existing user are completed, not overwritten with empty values)
+func AddDomainUser(db *gorm.DB, h *Host, domain, username string, rid int, fullName, description string) *DomainUser {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &DomainUser{}
+	db.Where(DomainUser{HostID: h.ID, Username: username}).
+		Assign(DomainUser{Domain: domain, RID: rid, FullName: fullName, Description: description}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func GetAllDomainUsers(db *gorm.DB) []DomainUser {
+	users := []DomainUser{}
+	db.Order("host_id, rid, username").Find(&users)
+	return users
+}
+
+func (h *Host) GetDomainUsers(db *gorm.DB) []DomainUser {
+	users := []DomainUser{}
+	db.Where("host_id = ?", h.ID).Order("rid, username").Find(&users)
+	return users
+}
+
+func (u *DomainUser) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", u.HostID).Find(&host)
+	return host
+}
+
+// ---------------------------------------------------------------------------------------
+// DOMAIN GROUP
+// ---------------------------------------------------------------------------------------
+const (
+	GROUP_BUILTIN = "builtin"
+	GROUP_LOCAL   = "local"
+	GROUP_DOMAIN  = "domain"
+)
+
+type DomainGroup struct {
+	ID     uint   `gorm:"primary_key"`
+	HostID uint   `gorm:"unique_index:idx_domain_group"`
+	Name   string `gorm:"unique_index:idx_domain_group"`
+	RID    int    `gorm:"column:rid"`
+	Kind   string
+}
+
+type GroupMember struct {
+	ID      uint   `gorm:"primary_key"`
+	GroupID uint   `gorm:"unique_index:idx_group_member"`
+	Member  string `gorm:"unique_index:idx_group_member"`
+}
+
+// Print to string
+func (g *DomainGroup) String() string {
+	return fmt.Sprintf("%s (%s)", g.Name, g.Kind)
+}
+
+// Constructor (fields of an existing group are completed, not overwritten with empty values)
+func AddDomainGroup(db *gorm.DB, h *Host, name string, rid int, kind string) *DomainGroup {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &DomainGroup{}
+	db.Where(DomainGroup{HostID: h.ID, Name: name}).
+		Assign(DomainGroup{RID: rid, Kind: kind}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Duplicates are silently ignored
+func (g *DomainGroup) AddMember(db *gorm.DB, member string) *GroupMember {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &GroupMember{
+		GroupID: g.ID,
+		Member:  member,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters
+func GetAllDomainGroups(db *gorm.DB) []DomainGroup {
+	groups := []DomainGroup{}
+	db.Order("host_id, kind, name").Find(&groups)
+	return groups
+}
+
+func (h *Host) GetDomainGroups(db *gorm.DB) []DomainGroup {
+	groups := []DomainGroup{}
+	db.Where("host_id = ?", h.ID).Order("kind, name").Find(&groups)
+	return groups
+}
+
+func (g *DomainGroup) GetMembers(db *gorm.DB) []string {
+	members := []GroupMember{}
+	db.Where("group_id = ?", g.ID).Order("member").Find(&members)
+	names := []string{}
+	for _, m := range members {
+		names = append(names, m.Member)
+	}
+	return names
+}
+
+func (g *DomainGroup) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", g.HostID).Find(&host)
+	return host
+}
+
+// ---------------------------------------------------------------------------------------
+// SHARE
+// ---------------------------------------------------------------------------------------
+// SMB share, with the access obtained during the enumeration (e.g., "Mapping: OK, Listing: OK")
+type Share struct {
+	ID      uint   `gorm:"primary_key"`
+	HostID  uint   `gorm:"unique_index:idx_share"`
+	Name    string `gorm:"unique_index:idx_share"`
+	Type    string
+	Comment string
+	Access  string
+}
+
+// Print to string
+func (s *Share) String() string {
+	return fmt.Sprintf("%s [%s] %s", s.Name, s.Type, s.Access)
+}
+
+// Constructor (fields of an existing share are completed, not overwritten with empty values)
+func AddShare(db *gorm.DB, h *Host, name, kind, comment, access string) *Share {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Share{}
+	db.Where(Share{HostID: h.ID, Name: name}).
+		Assign(Share{Type: kind, Comment: comment, Access: access}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func GetAllShares(db *gorm.DB) []Share {
+	shares := []Share{}
+	db.Order("host_id, name").Find(&shares)
+	return shares
+}
+
+func (h *Host) GetShares(db *gorm.DB) []Share {
+	shares := []Share{}
+	db.Where("host_id = ?", h.ID).Order("name").Find(&shares)
+	return shares
+}
+
+func (s *Share) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", s.HostID).Find(&host)
+	return host
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// CREDENTIAL
+// ---------------------------------------------------------------------------------------
+// Credential found for a service (e.g., by hydra or the *-brute NSE scripts).
+// The secret is stored encrypted with the key of the workspace (see utils.Encrypt),
+// and it is empty if the vault was locked when the credential was found
+type Credential struct {
+	ID       uint   `gorm:"primary_key"`
+	HostID   uint   `gorm:"unique_index:idx_credential"`
+	Port     int    `gorm:"unique_index:idx_credential"`
+	Username string `gorm:"unique_index:idx_credential"`
+	Service  string
+	Secret   string
+	Source   string
+	Verified bool
+}
+
+// Print to string
+func (c *Credential) String() string {
+	return fmt.Sprintf("%s@%d/%s (%s)", c.Username, c.Port, c.Service, c.Source)
+}
+
+// Constructor (fields of an existing credential are completed, not overwritten with empty values)
+func AddCredential(db *gorm.DB, h *Host, port int, service, username, secret, source string, verified bool) *Credential {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Credential{}
+	db.Where(Credential{HostID: h.ID, Port: port, Username: username}).
+		Assign(Credential{Service: service, Secret: secret, Source: source, Verified: verified}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func GetAllCredentials(db *gorm.DB) []Credential {
+	creds := []Credential{}
+	db.Order("host_id, port, username").Find(&creds)
+	return creds
+}
+
+func (h *Host) GetCredentials(db *gorm.DB) []Credential {
+	creds := []Credential{}
+	db.Where("host_id = ?", h.ID).Order("port, username").Find(&creds)
+	return creds
+}
+
+func (c *Credential) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", c.HostID).Find(&host)
+	return host
+}
+
+// ---------------------------------------------------------------------------------------
+// SPRAY ATTEMPT
+// ---------------------------------------------------------------------------------------
+// Login attempted by `spray` with a known credential: an account (username, for a service
+// of a host) is only ever tried once
+type SprayAttempt struct {
+	ID       uint   `gorm:"primary_key"`
+	HostID   uint   `gorm:"unique_index:idx_spray_attempt"`
+	Service  string `gorm:"unique_index:idx_spray_attempt"`
+	Username string `gorm:"unique_index:idx_spray_attempt"`
+	Port     int
+	Success  bool
+}
+
+// Constructor
+func AddSprayAttempt(db *gorm.DB, h *Host, port int, service, username string, success bool) *SprayAttempt {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &SprayAttempt{
+		HostID:   h.ID,
+		Service:  service,
+		Username: username,
+		Port:     port,
+		Success:  success,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters
+func (h *Host) HasSprayAttempt(db *gorm.DB, service, username string) bool {
+	count := 0
+	db.Model(&SprayAttempt{}).Where("host_id = ? AND service = ? AND username = ?", h.ID, service, username).Count(&count)
+	return count > 0
+}
+
+// Failed attempts for an account against any of the given hosts (e.g., the hosts of a domain)
+func CountFailedSprayAttempts(db *gorm.DB, hosts []Host, service, username string) int {
+	ids := []uint{}
+	for _, h := range hosts {
+		ids = append(ids, h.ID)
+	}
+	count := 0
+	if len(ids) > 0 {
+		db.Model(&SprayAttempt{}).Where("host_id IN (?) AND service = ? AND username = ? AND success = ?", ids, service, username, false).Count(&count)
+	}
+	return count
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// DETAIL
+// ---------------------------------------------------------------------------------------
+// Structured result extracted by the enumeration modules (e.g., LDAP naming contexts)
+type Detail struct {
+	ID     uint   `gorm:"primary_key"`
+	HostID uint   `gorm:"unique_index:idx_detail"`
+	Port   int    `gorm:"unique_index:idx_detail"`
+	Source string `gorm:"unique_index:idx_detail"`
+	Key    string `gorm:"unique_index:idx_detail"`
+	Value  string `gorm:"unique_index:idx_detail"`
+}
+
+// Print to string
+func (d *Detail) String() string {
+	return fmt.Sprintf("%s: %s", d.Key, d.Value)
+}
+
+// Constructor (duplicates are silently ignored)
+func AddDetail(db *gorm.DB, h *Host, port int, source, key, value string) *Detail {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Detail{
+		HostID: h.ID,
+		Port:   port,
+		Source: source,
+		Key:    key,
+		Value:  value,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters
+func GetAllDetails(db *gorm.DB) []Detail {
+	details := []Detail{}
+	db.Order("host_id, source, port, key").Find(&details)
+	return details
+}
+
+func (h *Host) GetDetails(db *gorm.DB) []Detail {
+	details := []Detail{}
+	db.Where("host_id = ?", h.ID).Order("source, port, key").Find(&details)
+	return details
+}
+
+func (h *Host) GetDetailValues(db *gorm.DB, key string) []string {
+	values := []string{}
+	for _, d := range h.GetDetails(db) {
+		if d.Key == key {
+			values = append(values, d.Value)
+		}
+	}
+	return values
+}
+
+func (d *Detail) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", d.HostID).Find(&host)
+	return host
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// DOMAIN
+// ---------------------------------------------------------------------------------------
+// Windows domain, named after its DNS name (or its NetBIOS name when the DNS one is unknown).
+// Hosts are linked to it through Host.Domain
+type Domain struct {
+	ID          uint   `gorm:"primary_key"`
+	Name        string `gorm:"unique_index:idx_domain"`
+	NetBIOSName string `gorm:"column:netbios_name"`
+	Forest      string
+}
+
+// Print to string
+func (d *Domain) String() string {
+	if d.NetBIOSName != "" && !strings.EqualFold(d.NetBIOSName, d.Name) {
+		return fmt.Sprintf("%s (%s)", d.Name, d.NetBIOSName)
+	}
+	return d.Name
+}
+
+// Constructor (fields of an existing domain are completed, not overwritten with empty values)
+func AddDomain(db *gorm.DB, name, netbios, forest string) *Domain {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Domain{}
+	db.Where(Domain{Name: strings.ToLower(name)}).
+		Assign(Domain{NetBIOSName: strings.ToUpper(netbios), Forest: strings.ToLower(forest)}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func GetAllDomains(db *gorm.DB) []Domain {
+	domains := []Domain{}
+	db.Order("name").Find(&domains)
+	return domains
+}
+
+func (d *Domain) GetHosts(db *gorm.DB) []Host {
+	return GetHostByDomain(db, d.Name)
+}
+
+// Hosts registering the <1C> group name of the domain
+func (d *Domain) GetControllers(db *gorm.DB) []Host {
+	hosts := []Host{}
+	if d.NetBIOSName == "" {
+		return hosts
+	}
+	names := []NetBIOSName{}
+	db.Where("name = ? AND suffix = ? AND type = ?", d.NetBIOSName, "1C", NETBIOS_GROUP).Find(&names)
+	ids := []uint{}
+	for _, n := range names {
+		ids = append(ids, n.HostID)
+	}
+	if len(ids) > 0 {
+		db.Where("id IN (?)", ids).Order("address").Find(&hosts)
+	}
+	return hosts
+}
+
+// ---------------------------------------------------------------------------------------
+// NETBIOS NAME
+// ---------------------------------------------------------------------------------------
+const (
+	NETBIOS_UNIQUE = "unique"
+	NETBIOS_GROUP  = "group"
+)
+
+// Role of a NetBIOS name, by suffix and type
+var netbiosRoles = map[string]string{
+	"00/unique": "Workstation Service",
+	"00/group":  "Domain Name",
+	"01/unique": "Messenger Service",
+	"01/group":  "Master Browser",
+	"03/unique": "Messenger Service",
+	"06/unique": "RAS Server Service",
+	"1B/unique": "Domain Master Browser",
+	"1C/group":  "Domain Controllers",
+	"1D/unique": "Master Browser",
+	"1E/group":  "Browser Service Elections",
+	"20/unique": "File Server Service",
+	"21/unique": "RAS Client Service",
+}
+
+// Name registered by a host (e.g., from nbstat), with its suffix in hex (e.g., "1C")
+type NetBIOSName struct {
+	ID     uint   `gorm:"primary_key"`
+	HostID uint   `gorm:"unique_index:idx_netbios_name"`
+	Name   string `gorm:"unique_index:idx_netbios_name"`
+	Suffix string `gorm:"unique_index:idx_netbios_name"`
+	Type   string `gorm:"unique_index:idx_netbios_name"`
+}
+
+// Print to string
+func (n *NetBIOSName) String() string {
+	return fmt.Sprintf("%s<%s> %s", n.Name, n.Suffix, n.Type)
+}
+
+func (n *NetBIOSName) Role() string {
+	return netbiosRoles[fmt.Sprintf("%s/%s", n.Suffix, n.Type)]
+}
+
+// Constructor (duplicates are silently ignored)
+func AddNetBIOSName(db *gorm.DB, h *Host, name, suffix, kind string) *NetBIOSName {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &NetBIOSName{
+		HostID: h.ID,
+		Name:   name,
+		Suffix: strings.ToUpper(suffix),
+		Type:   kind,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters
+func GetAllNetBIOSNames(db *gorm.DB) []NetBIOSName {
+	names := []NetBIOSName{}
+	db.Order("suffix, type, name, host_id").Find(&names)
+	return names
+}
+
+func (h *Host) GetNetBIOSNames(db *gorm.DB) []NetBIOSName {
+	names := []NetBIOSName{}
+	db.Where("host_id = ?", h.ID).Order("suffix, type, name").Find(&names)
+	return names
+}
+
+func (n *NetBIOSName) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", n.HostID).Find(&host)
+	return host
+}
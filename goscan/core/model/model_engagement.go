@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// CONSTANTS
+// ---------------------------------------------------------------------------------------
+const (
+	WINDOW_ALLOW    = "ALLOW"
+	WINDOW_BLACKOUT = "BLACKOUT"
+
+	WINDOW_ACTION_PAUSE  = "PAUSE"
+	WINDOW_ACTION_CANCEL = "CANCEL"
+)
+
+// Accepted layouts: daily recurring windows ("22:00") or absolute periods ("2026-12-24T00:00")
+const (
+	windowLayoutDaily    = "15:04"
+	windowLayoutAbsolute = "2006-01-02T15:04"
+)
+
+// ---------------------------------------------------------------------------------------
+// WINDOW
+// ---------------------------------------------------------------------------------------
+// Rules of engagement: an allowed time window or a blackout period (times are UTC)
+type Window struct {
+	ID     uint   `gorm:"primary_key"`
+	Kind   string `gorm:"unique_index:idx_window"`
+	Start  string `gorm:"unique_index:idx_window"`
+	End    string `gorm:"unique_index:idx_window"`
+	Action string
+}
+
+// Print to string
+func (w *Window) String() string {
+	return fmt.Sprintf("%s %s-%s UTC (%s)", w.Kind, w.Start, w.End, w.Action)
+}
+
+// Constructor
+func AddWindow(db *gorm.DB, kind, start, end, action string) (*Window, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, _, err := parseWindow(start, end); err != nil {
+		return nil, err
+	}
+	t := &Window{
+		Kind:   kind,
+		Start:  start,
+		End:    end,
+		Action: action,
+	}
+	if err := db.Create(t).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Getters
+func GetAllWindows(db *gorm.DB) []Window {
+	windows := []Window{}
+	db.Find(&windows)
+	return windows
+}
+
+func DeleteAllWindows(db *gorm.DB) {
+	lock.Lock()
+	defer lock.Unlock()
+	db.Delete(&Window{})
+}
+
+// Returns true if the window is daily recurring (as opposed to an absolute period)
+func (w *Window) IsDaily() bool {
+	daily, _, _ := parseWindow(w.Start, w.End)
+	return daily
+}
+
+// Returns true if the given time falls within the window
+func (w *Window) Contains(now time.Time) bool {
+	daily, bounds, err := parseWindow(w.Start, w.End)
+	if err != nil {
+		return false
+	}
+	now = now.UTC()
+	if !daily {
+		return !now.Before(bounds[0]) && now.Before(bounds[1])
+	}
+	// Compare minutes since midnight, windows can wrap around midnight (e.g. 22:00-06:00)
+	cur := now.Hour()*60 + now.Minute()
+	start := bounds[0].Hour()*60 + bounds[0].Minute()
+	end := bounds[1].Hour()*60 + bounds[1].Minute()
+	if start <= end {
+		return cur >= start && cur < end
+	}
+	return cur >= start || cur < end
+}
+
+// Returns the next time (after now) the window starts or ends
+func (w *Window) NextBoundary(now time.Time, start bool) time.Time {
+	daily, bounds, err := parseWindow(w.Start, w.End)
+	if err != nil {
+		return time.Time{}
+	}
+	b := bounds[1]
+	if start {
+		b = bounds[0]
+	}
+	if !daily {
+		return b
+	}
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), b.Hour(), b.Minute(), 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+func parseWindow(start, end string) (bool, [2]time.Time, error) {
+	var bounds [2]time.Time
+	for _, layout := range []string{windowLayoutDaily, windowLayoutAbsolute} {
+		s, errS := time.ParseInLocation(layout, start, time.UTC)
+		e, errE := time.ParseInLocation(layout, end, time.UTC)
+		if errS == nil && errE == nil {
+			bounds[0], bounds[1] = s, e
+			if layout == windowLayoutAbsolute && !e.After(s) {
+				return false, bounds, fmt.Errorf("end of period must be after its start: %s-%s", start, end)
+			}
+			return layout == windowLayoutDaily, bounds, nil
+		}
+	}
+	return false, bounds, fmt.Errorf("invalid window %s-%s, use HH:MM or YYYY-MM-DDTHH:MM (UTC)", start, end)
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02T15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWindowContains(t *testing.T) {
+	cases := []struct {
+		start, end string
+		now        string
+		want       bool
+	}{
+		// Daily windows, the end is excluded
+		{"08:00", "18:00", "2026-03-02T08:00", true},
+		{"08:00", "18:00", "2026-03-02T17:59", true},
+		{"08:00", "18:00", "2026-03-02T18:00", false},
+		{"08:00", "18:00", "2026-03-02T07:59", false},
+		// Wrapping around midnight
+		{"22:00", "06:00", "2026-03-02T22:00", true},
+		{"22:00", "06:00", "2026-03-02T23:59", true},
+		{"22:00", "06:00", "2026-03-03T00:00", true},
+		{"22:00", "06:00", "2026-03-03T05:59", true},
+		{"22:00", "06:00", "2026-03-03T06:00", false},
+		{"22:00", "06:00", "2026-03-03T12:00", false},
+		// Same start and end: never open
+		{"09:00", "09:00", "2026-03-02T09:00", false},
+		// Dated periods, across days
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2026-12-24T18:00", true},
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2026-12-25T03:00", true},
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2026-12-26T08:00", false},
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2026-12-23T20:00", false},
+		// The time of day of a dated period does not recur
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2027-12-25T03:00", false},
+		// Invalid windows contain nothing
+		{"2026-12-26T08:00", "2026-12-24T18:00", "2026-12-25T03:00", false},
+		{"08:00", "2026-12-24T18:00", "2026-03-02T12:00", false},
+	}
+	for _, c := range cases {
+		w := Window{Kind: WINDOW_ALLOW, Start: c.start, End: c.end}
+		if got := w.Contains(at(c.now)); got != c.want {
+			t.Errorf("%s-%s at %s: got %v, want %v", c.start, c.end, c.now, got, c.want)
+		}
+	}
+}
+
+func TestWindowNextBoundary(t *testing.T) {
+	cases := []struct {
+		start, end string
+		now        string
+		opening    bool
+		want       string
+	}{
+		{"08:00", "18:00", "2026-03-02T07:00", true, "2026-03-02T08:00"},
+		{"08:00", "18:00", "2026-03-02T08:00", true, "2026-03-03T08:00"},
+		{"08:00", "18:00", "2026-03-02T12:00", false, "2026-03-02T18:00"},
+		// Wrapping around midnight: closes the next day
+		{"22:00", "06:00", "2026-03-02T23:00", false, "2026-03-03T06:00"},
+		{"22:00", "06:00", "2026-03-31T23:00", false, "2026-04-01T06:00"},
+		{"22:00", "06:00", "2026-03-03T02:00", true, "2026-03-03T22:00"},
+		// Dated periods have fixed bounds
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2026-12-25T03:00", false, "2026-12-26T08:00"},
+		{"2026-12-24T18:00", "2026-12-26T08:00", "2026-12-25T03:00", true, "2026-12-24T18:00"},
+	}
+	for _, c := range cases {
+		w := Window{Kind: WINDOW_ALLOW, Start: c.start, End: c.end}
+		if got := w.NextBoundary(at(c.now), c.opening); !got.Equal(at(c.want)) {
+			t.Errorf("%s-%s at %s (opening: %v): got %s, want %s", c.start, c.end, c.now, c.opening, got, c.want)
+		}
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	cases := []struct {
+		start, end string
+		daily, ok  bool
+	}{
+		{"22:00", "06:00", true, true},
+		{"2026-12-24T00:00", "2026-12-25T00:00", false, true},
+		{"2026-12-24T00:00", "2026-12-24T00:00", false, false},
+		{"2026-12-25T00:00", "2026-12-24T00:00", false, false},
+		{"22:00", "2026-12-25T00:00", false, false},
+		{"25:00", "06:00", false, false},
+		{"10pm", "6am", false, false},
+	}
+	for _, c := range cases {
+		daily, _, err := parseWindow(c.start, c.end)
+		if (err == nil) != c.ok || (c.ok && daily != c.daily) {
+			t.Errorf("%s-%s: got daily %v, error %v", c.start, c.end, daily, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// FINDING
+// ---------------------------------------------------------------------------------------
+const (
+	SEVERITY_INFO     = "INFO"
+	SEVERITY_LOW      = "LOW"
+	SEVERITY_MEDIUM   = "MEDIUM"
+	SEVERITY_HIGH     = "HIGH"
+	SEVERITY_CRITICAL = "CRITICAL"
+)
+
+var severityRank = map[string]int{
+	SEVERITY_INFO:     0,
+	SEVERITY_LOW:      1,
+	SEVERITY_MEDIUM:   2,
+	SEVERITY_HIGH:     3,
+	SEVERITY_CRITICAL: 4,
+}
+
+// Issue flagged by the enumeration modules (e.g., world-readable NFS export)
+type Finding struct {
+	ID       uint   `gorm:"primary_key"`
+	HostID   uint   `gorm:"unique_index:idx_finding"`
+	Port     int    `gorm:"unique_index:idx_finding"`
+	Source   string `gorm:"unique_index:idx_finding"`
+	Title    string `gorm:"unique_index:idx_finding"`
+	Detail   string `gorm:"unique_index:idx_finding"`
+	Severity string
+}
+
+// Print to string
+func (f *Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Severity, f.Title, f.Detail)
+}
+
+// Constructor (duplicates are silently ignored)
+func AddFinding(db *gorm.DB, h *Host, port int, source, severity, title, detail string) *Finding {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Finding{
+		HostID:   h.ID,
+		Port:     port,
+		Source:   source,
+		Severity: severity,
+		Title:    title,
+		Detail:   detail,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters (most severe first)
+func GetAllFindings(db *gorm.DB) []Finding {
+	findings := []Finding{}
+	db.Order("host_id, port").Find(&findings)
+	sortFindings(findings)
+	return findings
+}
+
+func (h *Host) GetFindings(db *gorm.DB) []Finding {
+	findings := []Finding{}
+	db.Where("host_id = ?", h.ID).Order("port").Find(&findings)
+	sortFindings(findings)
+	return findings
+}
+
+func (f *Finding) GetHost(db *gorm.DB) *Host {
+	host := &Host{}
+	db.Where("id = ?", f.HostID).Find(&host)
+	return host
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] > severityRank[findings[j].Severity]
+	})
+}
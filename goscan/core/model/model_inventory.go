@@ -0,0 +1,150 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// PROCESS
+// ---------------------------------------------------------------------------------------
+// Process running on a host (e.g., from the SNMP Host Resources MIB)
+type Process struct {
+	ID     uint   `gorm:"primary_key"`
+	HostID uint   `gorm:"unique_index:idx_process"`
+	PID    int    `gorm:"column:pid;unique_index:idx_process"`
+	Name   string `gorm:"unique_index:idx_process"`
+	Path   string
+	Args   string
+}
+
+// Print to string
+func (p *Process) String() string {
+	return fmt.Sprintf("%d %s", p.PID, p.Name)
+}
+
+// Constructor (fields of an existing process are completed, not overwritten with empty values)
+func AddProcess(db *gorm.DB, h *Host, pid int, name, path, args string) *Process {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Process{}
+	db.Where(Process{HostID: h.ID, PID: pid, Name: name}).
+		Assign(Process{Path: path, Args: args}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func (h *Host) GetProcesses(db *gorm.DB) []Process {
+	processes := []Process{}
+	db.Where("host_id = ?", h.ID).Order("pid").Find(&processes)
+	return processes
+}
+
+// ---------------------------------------------------------------------------------------
+// SOFTWARE
+// ---------------------------------------------------------------------------------------
+// Software installed on a host, named as reported (usually with its version)
+type Software struct {
+	ID     uint   `gorm:"primary_key"`
+	HostID uint   `gorm:"unique_index:idx_software"`
+	Name   string `gorm:"unique_index:idx_software"`
+}
+
+// Constructor (duplicates are silently ignored)
+func AddSoftware(db *gorm.DB, h *Host, name string) *Software {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Software{
+		HostID: h.ID,
+		Name:   name,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters
+func (h *Host) GetSoftware(db *gorm.DB) []Software {
+	software := []Software{}
+	db.Where("host_id = ?", h.ID).Order("name").Find(&software)
+	return software
+}
+
+// ---------------------------------------------------------------------------------------
+// LISTENER
+// ---------------------------------------------------------------------------------------
+// Port a host listens on, as reported by the host itself (it might not be reachable)
+type Listener struct {
+	ID       uint   `gorm:"primary_key"`
+	HostID   uint   `gorm:"unique_index:idx_listener"`
+	Protocol string `gorm:"unique_index:idx_listener"`
+	Address  string `gorm:"unique_index:idx_listener"`
+	Port     int    `gorm:"unique_index:idx_listener"`
+}
+
+// Print to string
+func (l *Listener) String() string {
+	return fmt.Sprintf("%s %s:%d", l.Protocol, l.Address, l.Port)
+}
+
+// Constructor (duplicates are silently ignored)
+func AddListener(db *gorm.DB, h *Host, protocol, address string, port int) *Listener {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Listener{
+		HostID:   h.ID,
+		Protocol: protocol,
+		Address:  address,
+		Port:     port,
+	}
+	db.Create(t)
+	return t
+}
+
+// Getters
+func (h *Host) GetListeners(db *gorm.DB) []Listener {
+	listeners := []Listener{}
+	db.Where("host_id = ?", h.ID).Order("protocol, port, address").Find(&listeners)
+	return listeners
+}
+
+// ---------------------------------------------------------------------------------------
+// INTERFACE
+// ---------------------------------------------------------------------------------------
+// Network interface of a host; its addresses (CIDR) are stored comma-separated
+type Interface struct {
+	ID        uint `gorm:"primary_key"`
+	HostID    uint `gorm:"unique_index:idx_interface"`
+	Index     int  `gorm:"column:if_index;unique_index:idx_interface"`
+	Name      string
+	MAC       string
+	Addresses string
+}
+
+// Print to string
+func (i *Interface) String() string {
+	return fmt.Sprintf("%d %s %s %s", i.Index, i.Name, i.MAC, i.Addresses)
+}
+
+// Constructor (fields of an existing interface are completed, not overwritten with empty values)
+func AddInterface(db *gorm.DB, h *Host, index int, name, mac, addresses string) *Interface {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &Interface{}
+	db.Where(Interface{HostID: h.ID, Index: index}).
+		Assign(Interface{Name: name, MAC: mac, Addresses: addresses}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func (h *Host) GetInterfaces(db *gorm.DB) []Interface {
+	interfaces := []Interface{}
+	db.Where("host_id = ?", h.ID).Order("if_index").Find(&interfaces)
+	return interfaces
+}
@@ -10,6 +10,11 @@ import (
 // ---------------------------------------------------------------------------------------
 var Mutex sync.Mutex
 
+var (
+	jobLock    sync.Mutex
+	jobCounter int
+)
+
 const (
 	NULL = iota
 	NOT_STARTED
@@ -17,15 +22,36 @@ const (
 	FAILED
 	DONE
 	FINISHED
+	WAITING
+	CANCELLED
 )
 
+func StatusString(status int) string {
+	names := [...]string{"NULL", "NOT_STARTED", "IN_PROGRESS", "FAILED", "DONE", "FINISHED", "WAITING", "CANCELLED"}
+	if status < 0 || status >= len(names) {
+		return "UNKNOWN"
+	}
+	return names[status]
+}
+
+// Returns a new identifier, unique within the session, for a scan or enumeration job
+func NextJobID() int {
+	jobLock.Lock()
+	defer jobLock.Unlock()
+	jobCounter++
+	return jobCounter
+}
+
 // ---------------------------------------------------------------------------------------
 // SCAN STRUCTURE
 // ---------------------------------------------------------------------------------------
 type Scan struct {
+	ID        int
 	Name      string
 	Target    string
 	Status    int
+	Reason    string
+	Origin    string
 	Outfolder string
 	Outfile   string
 	Cmd       string
@@ -39,10 +65,13 @@ func (s *Scan) String() string {
 // ENUMERATE STRUCTURE
 // ---------------------------------------------------------------------------------------
 type Enumeration struct {
+	ID        int
 	Target    *Host
 	Outfolder string
 	Kind      string
 	Status    int
+	Reason    string
+	Origin    string
 	Result    []byte
 	Polite    string
 }
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ---------------------------------------------------------------------------------------
+// WEB
+// ---------------------------------------------------------------------------------------
+// Fingerprint of a web service, as seen by the native HTTP probe (one per Service).
+// Lists (redirects, headers, SANs, technologies) are stored comma-separated
+type Web struct {
+	ID              uint `gorm:"primary_key"`
+	ServiceID       uint `gorm:"unique_index:idx_web"`
+	URL             string
+	StatusCode      int
+	Title           string
+	Server          string
+	Redirects       string
+	SecurityHeaders string
+	MissingHeaders  string
+	FaviconHash     string
+	CertSubject     string
+	CertIssuer      string
+	CertSANs        string
+	CertNotAfter    string
+	Technologies    string
+}
+
+// Print to string
+func (w *Web) String() string {
+	return fmt.Sprintf("%s [%d] %s", w.URL, w.StatusCode, w.Title)
+}
+
+// Constructor (replaces the previous fingerprint of the service)
+func SetWeb(db *gorm.DB, s *Service, w *Web) *Web {
+	lock.Lock()
+	defer lock.Unlock()
+
+	w.ServiceID = s.ID
+	db.Where("service_id = ?", s.ID).Delete(&Web{})
+	db.Create(w)
+	return w
+}
+
+// Getters
+func GetAllWeb(db *gorm.DB) []Web {
+	web := []Web{}
+	db.Order("service_id").Find(&web)
+	return web
+}
+
+func (s *Service) GetWeb(db *gorm.DB) *Web {
+	web := &Web{}
+	db.Where("service_id = ?", s.ID).Find(&web)
+	return web
+}
+
+func (w *Web) GetService(db *gorm.DB) *Service {
+	srv := &Service{}
+	db.Where("id = ?", w.ServiceID).Find(&srv)
+	return srv
+}
+
+// ---------------------------------------------------------------------------------------
+// WEB URL
+// ---------------------------------------------------------------------------------------
+// URL discovered on a web service (e.g., by dirb or the sqlmap crawler).
+// The status code is 0 when unknown (e.g., directories listed by dirb)
+type WebURL struct {
+	ID         uint   `gorm:"primary_key"`
+	ServiceID  uint   `gorm:"unique_index:idx_web_url"`
+	URL        string `gorm:"unique_index:idx_web_url"`
+	StatusCode int
+	Size       int
+	Source     string
+}
+
+// Print to string
+func (u *WebURL) String() string {
+	return fmt.Sprintf("%s [%d] (%s)", u.URL, u.StatusCode, u.Source)
+}
+
+// Constructor (fields of an existing URL are completed, not overwritten with empty values)
+func AddWebURL(db *gorm.DB, s *Service, url string, status, size int, source string) *WebURL {
+	lock.Lock()
+	defer lock.Unlock()
+
+	t := &WebURL{}
+	db.Where(WebURL{ServiceID: s.ID, URL: url}).
+		Assign(WebURL{StatusCode: status, Size: size, Source: source}).
+		FirstOrCreate(t)
+	return t
+}
+
+// Getters
+func GetAllWebURLs(db *gorm.DB) []WebURL {
+	urls := []WebURL{}
+	db.Order("service_id, url").Find(&urls)
+	return urls
+}
+
+func (s *Service) GetWebURLs(db *gorm.DB) []WebURL {
+	urls := []WebURL{}
+	db.Where("service_id = ?", s.ID).Order("url").Find(&urls)
+	return urls
+}
+
+func (u *WebURL) GetService(db *gorm.DB) *Service {
+	srv := &Service{}
+	db.Where("id = ?", u.ServiceID).Find(&srv)
+	return srv
+}
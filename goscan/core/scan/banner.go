@@ -0,0 +1,260 @@
+package scan
+
+import (
+	"crypto/tls"
+	_ "embed"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marco-lancini/goscan/core/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ---------------------------------------------------------------------------------------
+// BANNER GRABBING
+// ---------------------------------------------------------------------------------------
+// Lightweight service identification (no -sV): open ports are sent protocol-appropriate
+// probes, and the responses are matched against the embedded signatures
+//
+//go:embed signatures.yaml
+var builtinSignatures []byte
+
+const (
+	bannerReadTimeout = 3 * time.Second
+	bannerMaxSize     = 4096
+)
+
+type signature struct {
+	Probe   string `yaml:"probe"`
+	Match   string `yaml:"match"`
+	Service string `yaml:"service"`
+	Product string `yaml:"product"`
+	Version string `yaml:"version"`
+	Info    string `yaml:"info"`
+	regex   *regexp.Regexp
+}
+
+var signatures = loadSignatures()
+
+func loadSignatures() []signature {
+	sigs := []signature{}
+	if err := yaml.Unmarshal(builtinSignatures, &sigs); err != nil {
+		panic(fmt.Sprintf("invalid service signatures: %s", err))
+	}
+	for i := range sigs {
+		sigs[i].regex = regexp.MustCompile(sigs[i].Match)
+	}
+	return sigs
+}
+
+// Service identified on a port
+type bannerResult struct {
+	Service string
+	Product string
+	Version string
+	Info    string
+	Tunnel  string // "ssl" for services behind TLS
+	Banner  string // raw response (printable)
+}
+
+// Probes: "banner" sends nothing (services talking first: SSH, FTP, SMTP, MySQL...),
+// "tls" is a ClientHello followed by the HTTP probe
+var bannerPayloads = map[string]string{
+	"banner": "",
+	"http":   "GET / HTTP/1.0\r\nHost: %s\r\nUser-Agent: goscan\r\nAccept: */*\r\n\r\n",
+	"tls":    "GET / HTTP/1.0\r\nHost: %s\r\nUser-Agent: goscan\r\nAccept: */*\r\n\r\n",
+	"redis":  "*1\r\n$4\r\nINFO\r\n",
+}
+
+// Ports where a probe is worth trying first
+var bannerPreferred = map[int]string{
+	6379: "redis",
+	80:   "http", 81: "http", 2375: "http", 3000: "http", 5000: "http", 5984: "http", 5985: "http", 8000: "http",
+	8008: "http", 8080: "http", 8081: "http", 8888: "http", 9090: "http", 9200: "http", 10255: "http",
+	443: "tls", 465: "tls", 636: "tls", 993: "tls", 995: "tls", 2376: "tls", 3269: "tls", 5986: "tls",
+	6443: "tls", 8443: "tls", 9443: "tls", 10250: "tls",
+}
+
+// Order of the probes sent to a port
+func bannerProbes(port int) []string {
+	probes := []string{}
+	if p, ok := bannerPreferred[port]; ok {
+		probes = append(probes, p)
+	}
+	for _, p := range []string{"banner", "http", "tls"} {
+		if len(probes) == 0 || probes[0] != p {
+			probes = append(probes, p)
+		}
+	}
+	return probes
+}
+
+// Send a probe and read the response. For TLS, the negotiated version is returned too
+func bannerExchange(address string, port int, probe string, timeout time.Duration) ([]byte, string, error) {
+	target := net.JoinHostPort(address, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", target, timeout)
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+	tlsVersion := ""
+	if probe == "tls" {
+		config := &tls.Config{InsecureSkipVerify: true}
+		if net.ParseIP(address) == nil {
+			config.ServerName = address
+		}
+		client := tls.Client(conn, config)
+		client.SetDeadline(time.Now().Add(bannerReadTimeout))
+		if err := client.Handshake(); err != nil {
+			return nil, "", err
+		}
+		tlsVersion = tls.VersionName(client.ConnectionState().Version)
+		conn = client
+	}
+	if payload := bannerPayloads[probe]; payload != "" {
+		if strings.Contains(payload, "%s") {
+			payload = fmt.Sprintf(payload, address)
+		}
+		conn.SetWriteDeadline(time.Now().Add(timeout))
+		if _, err := conn.Write([]byte(payload)); err != nil {
+			return nil, tlsVersion, err
+		}
+	}
+	// Read until the peer stops talking: the whole wait for the first bytes, then
+	// a short one for the rest
+	buf := make([]byte, bannerMaxSize)
+	n := 0
+	conn.SetReadDeadline(time.Now().Add(bannerReadTimeout))
+	for n < len(buf) {
+		m, err := conn.Read(buf[n:])
+		n += m
+		if err != nil {
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	}
+	return buf[:n], tlsVersion, nil
+}
+
+// Match a response against the signatures of a probe
+func matchSignature(probe string, data []byte) *bannerResult {
+	if probe == "tls" {
+		probe = "http"
+	}
+	for _, sig := range signatures {
+		if sig.Probe != "" && sig.Probe != probe {
+			continue
+		}
+		m := sig.regex.FindSubmatchIndex(data)
+		if m == nil {
+			continue
+		}
+		expand := func(template string) string {
+			return strings.TrimSpace(string(sig.regex.Expand(nil, []byte(template), data, m)))
+		}
+		return &bannerResult{
+			Service: sig.Service,
+			Product: expand(sig.Product),
+			Version: expand(sig.Version),
+			Info:    expand(sig.Info),
+		}
+	}
+	return nil
+}
+
+// Printable version of a response
+func printableBanner(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		switch {
+		case c == '\r':
+		case c == '\n' || c == '\t' || (c >= 0x20 && c < 0x7f):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Error pages of HTTPS servers receiving plain HTTP: the TLS probe will tell more
+var plainHTTPToTLS = regexp.MustCompile(`(?i)HTTP request (was sent )?to (an )?HTTPS`)
+
+// Identify the service listening on a port. Returns nil if it did not answer any probe
+func grabBanner(address string, port int, timeout time.Duration) *bannerResult {
+	var unknown *bannerResult
+	for _, probe := range bannerProbes(port) {
+		data, tlsVersion, err := bannerExchange(address, port, probe, timeout)
+		if err != nil && tlsVersion == "" {
+			continue
+		}
+		if probe == "http" && plainHTTPToTLS.Match(data) {
+			continue
+		}
+		if res := matchSignature(probe, data); res != nil {
+			res.Banner = printableBanner(data)
+			if tlsVersion != "" {
+				res.Tunnel = "ssl"
+				if res.Service == "http" {
+					res.Service = "https"
+				}
+				res.Info = strings.TrimSpace(fmt.Sprintf("%s %s", res.Info, tlsVersion))
+			}
+			return res
+		}
+		// Something answered, but no signature matched: keep looking
+		if unknown == nil && (len(data) > 0 || tlsVersion != "") {
+			unknown = &bannerResult{Banner: printableBanner(data)}
+			if tlsVersion != "" {
+				unknown.Service, unknown.Tunnel, unknown.Info = "ssl", "ssl", tlsVersion
+			}
+		}
+	}
+	return unknown
+}
+
+// Grab the banners of the open ports of a host (at most workers at a time, within the
+// rate of the scanner), the transcript of the responses is returned
+func grabBanners(address string, ports []int, opts utils.NativeScanOptions, rate, workers int) (map[int]*bannerResult, string) {
+	type result struct {
+		port int
+		res  *bannerResult
+	}
+	limiter := time.NewTicker(time.Second / time.Duration(rate))
+	defer limiter.Stop()
+	jobs := make(chan int)
+	results := make(chan result)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for port := range jobs {
+				<-limiter.C
+				results <- result{port, grabBanner(address, port, opts.Timeout)}
+			}
+		}()
+	}
+	go func() {
+		for _, port := range ports {
+			jobs <- port
+		}
+		close(jobs)
+	}()
+
+	found := map[int]*bannerResult{}
+	for range ports {
+		r := <-results
+		if r.res != nil {
+			found[r.port] = r.res
+		}
+	}
+	var transcript strings.Builder
+	for _, port := range ports {
+		if r, ok := found[port]; ok {
+			fmt.Fprintf(&transcript, "%s:%d %s [%s %s] %s\n%s\n\n", address, port, r.Service, r.Product, r.Version, r.Info, r.Banner)
+		}
+	}
+	return found, transcript.String()
+}
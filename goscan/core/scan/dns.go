@@ -32,6 +32,7 @@ func ScanDNS(target string, kind string, baseIP string) {
 // ---------------------------------------------------------------------------------------
 func DNSDiscovery(target string) {
 	utils.Config.Log.LogNotify("Starting DNS Discovery...")
+	job := utils.Job{Kind: "DNS-DISCOVERY", Target: target}
 
 	// -----------------------------------------------------------------------------------
 	// NMAP
@@ -47,11 +48,11 @@ func DNSDiscovery(target string) {
 	utils.Config.Log.LogInfo("Running dnsrecon...")
 	outfile := filepath.Join(utils.Config.Outfolder, utils.CleanPath(target), "dns_dnsrecon")
 	cmd := fmt.Sprintf("dnsrecon -d %s > %s", target, outfile)
-	utils.ShellCmd(cmd)
+	utils.ShellCmdJob(job, cmd)
 
 	outfile = filepath.Join(utils.Config.Outfolder, utils.CleanPath(target), "dns_dnsrecon_axfr")
 	cmd = fmt.Sprintf("dnsrecon -d %s -t axfr > %s", target, outfile)
-	utils.ShellCmd(cmd)
+	utils.ShellCmdJob(job, cmd)
 
 	// -----------------------------------------------------------------------------------
 	// DNSENUM
@@ -59,13 +60,14 @@ func DNSDiscovery(target string) {
 	utils.Config.Log.LogInfo("Running dnsenum...")
 	outfile = filepath.Join(utils.Config.Outfolder, utils.CleanPath(target), "dns_dnsenum")
 	cmd = fmt.Sprintf("dnsenum --enum %s > %s", target, outfile)
-	utils.ShellCmd(cmd)
+	utils.ShellCmdJob(job, cmd)
 
 	utils.Config.Log.LogNotify("DNS Discovery Completed")
 }
 
 func DNSBruteforce(target string) {
 	utils.Config.Log.LogNotify("Starting DNS Bruteforce...")
+	job := utils.Job{Kind: "DNS-BRUTEFORCE", Target: target}
 
 	// -----------------------------------------------------------------------------------
 	// READ SOURCE FILE
@@ -83,7 +85,7 @@ func DNSBruteforce(target string) {
 		name := strings.TrimSpace(scanner.Text())
 		cmd := fmt.Sprintf("host %s.%s", name, target)
 
-		results, _ := utils.ShellCmd(cmd)
+		results, _ := utils.ShellCmdJob(job, cmd)
 		records := strings.Split(results, "\n")
 		for _, line := range records {
 			if strings.Contains(line, "has address") {
@@ -107,6 +109,7 @@ func DNSBruteforce(target string) {
 
 func DNSBruteforceReverse(target string, baseIP string) {
 	utils.Config.Log.LogNotify("Starting Reverse DNS Bruteforce...")
+	job := utils.Job{Kind: "DNS-BRUTEFORCE-REVERSE", Target: target}
 	lower, upper := 0, 255
 	hosts := []string{}
 	tokens := strings.Split(baseIP, ".")
@@ -118,7 +121,7 @@ func DNSBruteforceReverse(target string, baseIP string) {
 	for i := lower; i <= upper; i++ {
 		ip := fmt.Sprintf("%s.%d", prefix, i)
 		cmd := fmt.Sprintf("host %s", ip)
-		results, _ := utils.ShellCmd(cmd)
+		results, _ := utils.ShellCmdJob(job, cmd)
 		records := strings.Split(results, "\n")
 
 		for _, line := range records {
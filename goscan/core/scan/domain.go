@@ -1,14 +1,33 @@
 package scan
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	go_nmap "github.com/lair-framework/go-nmap"
+	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
+	"github.com/olekukonko/tablewriter"
 )
 
 // ---------------------------------------------------------------------------------------
 // DISPATCHER
 // ---------------------------------------------------------------------------------------
 func GatherDomain(kind string) {
+	if !utils.IsDBAvailable() {
+		utils.Config.Log.LogWarning("Database not available - cannot gather domain information")
+		return
+	}
+	// Refresh the domain model from the nmap results stored so far
+	ParseDomainInfo()
+
 	// Dispatch scan
 	switch kind {
 	case "users":
@@ -24,119 +43,325 @@ func GatherDomain(kind string) {
 }
 
 func gatherUsers() {
+	db := utils.Config.DB
+	hosts := map[uint]string{}
+	for _, h := range model.GetAllHosts(db) {
+		hosts[h.ID] = h.Address
+	}
+
 	utils.Config.Log.LogNotify("Users:")
-	cmd := fmt.Sprintf(`grep -r "Account" --include="*enum4linux*" --color=always %s | awk '{print $8}'`, utils.Config.Outfolder)
-	results, _ := utils.ShellCmd(cmd)
-	fmt.Printf(results)
+	users := model.GetAllDomainUsers(db)
+	if len(users) == 0 {
+		utils.Config.Log.LogInfo("No users enumerated yet, run the SMB enumeration first")
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Host", "Domain", "Username", "RID", "Name", "Description"})
+		table.SetAlignment(3)
+		table.SetAutoWrapText(false)
+		for _, u := range users {
+			rRID := ""
+			if u.RID != 0 {
+				rRID = strconv.Itoa(u.RID)
+			}
+			table.Append([]string{hosts[u.HostID], u.Domain, u.Username, rRID, u.FullName, u.Description})
+		}
+		table.Render()
+	}
+
+	utils.Config.Log.LogNotify("Groups:")
+	groups := model.GetAllDomainGroups(db)
+	if len(groups) == 0 {
+		utils.Config.Log.LogInfo("No groups enumerated yet, run the SMB enumeration first")
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Host", "Group", "Kind", "RID", "Members"})
+		table.SetRowLine(true)
+		table.SetAlignment(3)
+		table.SetAutoWrapText(false)
+		for _, g := range groups {
+			rRID := ""
+			if g.RID != 0 {
+				rRID = strconv.Itoa(g.RID)
+			}
+			table.Append([]string{hosts[g.HostID], g.Name, g.Kind, rRID, strings.Join(g.GetMembers(db), "\n")})
+		}
+		table.Render()
+	}
+
+	utils.Config.Log.LogNotify("NetBIOS users:")
+	for _, h := range model.GetAllHosts(db) {
+		for _, user := range h.GetDetailValues(db, DOMAIN_NETBIOS_USER) {
+			fmt.Printf("%s: %s\n", h.Address, user)
+		}
+	}
 
 	utils.Config.Log.LogNotify("SIDs:")
-	cmd = fmt.Sprintf(`grep -r "S-1" --include="*.nmap" --include="enum4linux" --exclude="vuln-scan.*" --color=always %s`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
+	for _, h := range model.GetAllHosts(db) {
+		for _, sid := range h.GetDetailValues(db, "smb_domain_sid") {
+			fmt.Printf("%s: %s\n", h.Address, sid)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------------------
+// PARSING
+// ---------------------------------------------------------------------------------------
+const (
+	DOMAIN_COMPUTER     = "netbios_computer"
+	DOMAIN_OS           = "smb_os"
+	DOMAIN_NETBIOS_MAC  = "netbios_mac"
+	DOMAIN_NETBIOS_USER = "netbios_user"
+)
+
+// What the NSE scripts disclosed about a host and its domain
+type domainInfo struct {
+	host     *model.Host
+	dns      string
+	netbios  string
+	forest   string
+	computer string
+	fqdn     string
+	os       string
+	mac      string
+	user     string
+	names    []model.NetBIOSName
+}
+
+// Fields of smb-os-discovery and of the *-ntlm-info scripts
+var domainFields = map[string]func(i *domainInfo, v string){
+	"OS":                    func(i *domainInfo, v string) { i.os = v },
+	"Computer name":         func(i *domainInfo, v string) { i.computer = v },
+	"NetBIOS computer name": func(i *domainInfo, v string) { i.computer = v },
+	"Domain name":           func(i *domainInfo, v string) { i.dns = v },
+	"Forest name":           func(i *domainInfo, v string) { i.forest = v },
+	"FQDN":                  func(i *domainInfo, v string) { i.fqdn = v },
+	"Workgroup":             func(i *domainInfo, v string) { i.netbios = v },
+	"NetBIOS domain name":   func(i *domainInfo, v string) { i.netbios = v },
+	"NetBIOS_Domain_Name":   func(i *domainInfo, v string) { i.netbios = v },
+	"NetBIOS_Computer_Name": func(i *domainInfo, v string) { i.computer = v },
+	"DNS_Domain_Name":       func(i *domainInfo, v string) { i.dns = v },
+	"DNS_Computer_Name":     func(i *domainInfo, v string) { i.fqdn = v },
+	"DNS_Tree_Name":         func(i *domainInfo, v string) { i.forest = v },
+}
+
+var (
+	nbstatSummary = regexp.MustCompile(`NetBIOS name: ([^,]*), NetBIOS user: ([^,]*), NetBIOS MAC: (.*)`)
+	nbstatName    = regexp.MustCompile(`^\s*(.+?)<([0-9a-fA-F]{2})>\s+Flags: <(unique|group)>`)
+)
+
+// Values are printed with a trailing "\x00" by smb-os-discovery
+func cleanValue(v string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), `\x00`))
+}
+
+func (i *domainInfo) parseFields(output string) {
+	for _, line := range strings.Split(output, "\n") {
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+		if set, ok := domainFields[strings.TrimSpace(line[:idx])]; ok {
+			if v := cleanValue(line[idx+1:]); v != "" {
+				set(i, v)
+			}
+		}
+	}
+}
+
+func (i *domainInfo) parseNbstat(output string) {
+	if m := nbstatSummary.FindStringSubmatch(output); m != nil {
+		i.computer = cleanValue(m[1])
+		if user := cleanValue(m[2]); user != "<unknown>" {
+			i.user = user
+		}
+		i.mac = cleanValue(m[3])
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if m := nbstatName.FindStringSubmatch(line); m != nil {
+			i.names = append(i.names, model.NetBIOSName{Name: strings.TrimSpace(m[1]), Suffix: strings.ToUpper(m[2]), Type: m[3]})
+		}
+	}
+}
+
+// Read the NSE scripts of a parsed nmap run
+func collectDomainInfo(run *go_nmap.NmapRun, infos map[uint]*domainInfo) {
+	for _, nh := range run.Hosts {
+		if len(nh.Addresses) == 0 {
+			continue
+		}
+		h := model.GetHostByAddress(utils.Config.DB, nh.Addresses[0].Addr)
+		if h.ID == 0 {
+			continue
+		}
+		info, ok := infos[h.ID]
+		if !ok {
+			info = &domainInfo{host: h}
+			infos[h.ID] = info
+		}
+		scripts := nh.HostScripts
+		for _, p := range nh.Ports {
+			scripts = append(scripts, p.Scripts...)
+		}
+		for _, sc := range scripts {
+			switch {
+			case sc.Id == "smb-os-discovery", strings.HasSuffix(sc.Id, "-ntlm-info"):
+				info.parseFields(sc.Output)
+			case sc.Id == "nbstat":
+				info.parseNbstat(sc.Output)
+			}
+		}
+	}
+}
+
+// Build the domain model (domains, NetBIOS names, host names and OS) from the NSE results
+// (smb-os-discovery, nbstat, *-ntlm-info) of every nmap XML in the output folder
+func ParseDomainInfo() {
+	db := utils.Config.DB
+	infos := map[uint]*domainInfo{}
+	filepath.Walk(utils.Config.Outfolder, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || filepath.Ext(path) != ".xml" {
+			return nil
+		}
+		dat, err := ioutil.ReadFile(path)
+		if err != nil || !bytes.Contains(dat, []byte("<nmaprun")) {
+			return nil
+		}
+		if run, err := go_nmap.Parse(dat); err == nil {
+			collectDomainInfo(run, infos)
+		} else {
+			utils.Config.Log.LogDebug(fmt.Sprintf("Cannot parse %s: %s", path, err))
+		}
+		return nil
+	})
+
+	// Hosts disclosing the DNS name of their domain first, so that NetBIOS-only
+	// information is attached to the same domain
+	ordered := []*domainInfo{}
+	for _, info := range infos {
+		ordered = append(ordered, info)
+	}
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return ordered[a].dns != "" && ordered[b].dns == ""
+	})
+	for _, info := range ordered {
+		h := info.host
+		for _, n := range info.names {
+			model.AddNetBIOSName(db, h, n.Name, n.Suffix, n.Type)
+			// A <1C> group name is a domain even if nothing else disclosed it
+			if n.Suffix == "1C" && n.Type == model.NETBIOS_GROUP && info.netbios == "" {
+				info.netbios = n.Name
+			}
+		}
+		if info.computer != "" {
+			model.AddDetail(db, h, 0, "DOMAIN", DOMAIN_COMPUTER, info.computer)
+		}
+		if info.os != "" {
+			model.AddDetail(db, h, 0, "DOMAIN", DOMAIN_OS, info.os)
+		}
+		if info.mac != "" {
+			model.AddDetail(db, h, 0, "DOMAIN", DOMAIN_NETBIOS_MAC, info.mac)
+		}
+		if info.user != "" {
+			model.AddDetail(db, h, 0, "DOMAIN", DOMAIN_NETBIOS_USER, info.user)
+		}
+		if info.fqdn != "" {
+			h.AddHostnames(db, []string{info.fqdn})
+		}
+
+		// Domain: by DNS name, or by NetBIOS name if already known (or as a last resort)
+		name := info.dns
+		if name == "" && info.netbios != "" {
+			name = info.netbios
+			for _, d := range model.GetAllDomains(db) {
+				if strings.EqualFold(d.NetBIOSName, info.netbios) {
+					name = d.Name
+				}
+			}
+		}
+		if name == "" {
+			continue
+		}
+		d := model.AddDomain(db, name, info.netbios, info.forest)
+		if h.Domain == "" {
+			h.SetDomain(db, d.Name)
+		}
+	}
 }
 
 func gatherHosts() {
-	utils.Config.Log.LogNotify("Computer name:")
-	cmd := fmt.Sprintf(`grep -r "Computer name" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s`, utils.Config.Outfolder)
-	results, _ := utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("NetBIOS Computer name:")
-	cmd = fmt.Sprintf(`grep -r "NetBIOS computer name" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("NetBIOS name:")
-	cmd = fmt.Sprintf(`grep -r "NetBIOS name" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | cut -d " " -f 1,3,4,5`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("NetBIOS MAC:")
-	cmd = fmt.Sprintf(`grep -r "NetBIOS MAC" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | cut -d " " -f 1,9,10,11`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("NetBIOS user:")
-	cmd = fmt.Sprintf(`grep -r "NetBIOS user" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | cut -d " " -f 1,6,7,8`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Domain name:")
-	cmd = fmt.Sprintf(`grep -r "Domain name" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Forest name:")
-	cmd = fmt.Sprintf(`grep -r "Forest name" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("FQDN:")
-	cmd = fmt.Sprintf(`grep -r "FQDN" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Summary:")
-	cmd = fmt.Sprintf(`find %s -type f -iname "enum4linux" | xargs winlanfoe.pl`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
+	db := utils.Config.DB
+
+	utils.Config.Log.LogNotify("Domains:")
+	domains := model.GetAllDomains(db)
+	if len(domains) == 0 {
+		utils.Config.Log.LogInfo("No domain identified yet, run the SMB/RDP enumeration first")
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Domain", "NetBIOS Name", "Forest", "Domain Controllers", "Hosts"})
+		table.SetRowLine(true)
+		table.SetAlignment(3)
+		table.SetAutoWrapText(false)
+		for _, d := range domains {
+			rDCs := []string{}
+			for _, h := range d.GetControllers(db) {
+				rDCs = append(rDCs, h.Address)
+			}
+			rHosts := []string{}
+			for _, h := range d.GetHosts(db) {
+				rHosts = append(rHosts, h.Address)
+			}
+			table.Append([]string{d.Name, d.NetBIOSName, d.Forest, strings.Join(rDCs, "\n"), strings.Join(rHosts, "\n")})
+		}
+		table.Render()
+	}
+
+	utils.Config.Log.LogNotify("Hosts:")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Address", "Computer Name", "Hostnames", "Domain", "OS", "NetBIOS MAC", "NetBIOS User"})
+	table.SetRowLine(true)
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+	found := 0
+	for _, h := range model.GetAllHosts(db) {
+		computer := strings.Join(h.GetDetailValues(db, DOMAIN_COMPUTER), "\n")
+		if computer == "" && h.Domain == "" && h.Hostnames == "" {
+			continue
+		}
+		table.Append([]string{h.Address, computer, strings.Replace(h.Hostnames, ",", "\n", -1), h.Domain,
+			strings.Join(h.GetDetailValues(db, DOMAIN_OS), "\n"),
+			strings.Join(h.GetDetailValues(db, DOMAIN_NETBIOS_MAC), "\n"),
+			strings.Join(h.GetDetailValues(db, DOMAIN_NETBIOS_USER), "\n")})
+		found++
+	}
+	if found == 0 {
+		utils.Config.Log.LogInfo("No host names identified yet, run the SMB/RDP enumeration first")
+		return
+	}
+	table.Render()
 }
 
 func gatherServers() {
+	db := utils.Config.DB
+	names := model.GetAllNetBIOSNames(db)
+	if len(names) == 0 {
+		utils.Config.Log.LogInfo("No NetBIOS names collected yet, run the SMB enumeration first (nbstat)")
+		return
+	}
 
-	utils.Config.Log.LogNotify("Hostname:")
-	cmd := fmt.Sprintf(`grep -riE "*<00>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ := utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Domain name:")
-	cmd = fmt.Sprintf(`grep -riE "*<00>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<group>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Domain Master Browser:")
-	cmd = fmt.Sprintf(`grep -riE "*<1B>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Domain Controllers:")
-	cmd = fmt.Sprintf(`grep -riE "*<1C>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<group>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Master Browser:")
-	cmd = fmt.Sprintf(`grep -riE "*<1D>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-	cmd = fmt.Sprintf(`grep -riE "\x01\x02__MSBROWSE__\x02<01>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<group>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Messenger Service:")
-	cmd = fmt.Sprintf(`grep -riE "*<01>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-	cmd = fmt.Sprintf(`grep -riE "*<03>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Remote Access Service:")
-	cmd = fmt.Sprintf(`grep -riE "*<06>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("Browser Service Elections:")
-	cmd = fmt.Sprintf(`grep -riE "*<1E>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<group>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("File Server Service:")
-	cmd = fmt.Sprintf(`grep -riE "*<20>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
-
-	utils.Config.Log.LogNotify("RAS Client Service:")
-	cmd = fmt.Sprintf(`grep -riE "*<21>" --include="*.nmap" --exclude="vuln-scan.*" --color=always %s | grep "<unique>"`, utils.Config.Outfolder)
-	results, _ = utils.ShellCmd(cmd)
-	fmt.Printf(results)
+	hosts := map[uint]string{}
+	for _, h := range model.GetAllHosts(db) {
+		hosts[h.ID] = h.Address
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Role", "Name", "Suffix", "Type", "Host"})
+	table.SetAlignment(3)
+	table.SetAutoWrapText(false)
+	for _, n := range names {
+		role := n.Role()
+		if role == "" {
+			role = "Other"
+		}
+		table.Append([]string{role, n.Name, fmt.Sprintf("<%s>", n.Suffix), n.Type, hosts[n.HostID]})
+	}
+	table.Render()
 }
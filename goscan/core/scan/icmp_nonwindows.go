@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package scan
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// Unprivileged ICMP datagram socket ("ping socket"), allowed to the groups in
+// net.ipv4.ping_group_range on Linux, and to everyone on macOS
+func listenICMPDatagram() (net.PacketConn, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_ICMP)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{}); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	f := os.NewFile(uintptr(fd), "icmp")
+	defer f.Close()
+	return net.FilePacketConn(f)
+}
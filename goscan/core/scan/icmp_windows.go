@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package scan
+
+import (
+	"errors"
+	"net"
+)
+
+// Windows has no unprivileged ICMP sockets: raw sockets (administrator) only
+func listenICMPDatagram() (net.PacketConn, error) {
+	return nil, errors.New("unprivileged ICMP sockets are not supported on Windows")
+}
@@ -0,0 +1,462 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	go_nmap "github.com/lair-framework/go-nmap"
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// NATIVE TCP CONNECT SCANNER
+// ---------------------------------------------------------------------------------------
+// Port scan profiles supported by the built-in scanner (no SYN, UDP nor NSE scripts)
+type nativeProfile struct {
+	Ports     string
+	Rate      int
+	Workers   int
+	NoBanners bool // fragile devices: no banner grabbing
+	Stored    bool // banner grabbing only, on the open ports already known
+}
+
+var nativeProfiles = map[string]nativeProfile{
+	"TCP-FULL":     {Ports: "1-65535"},
+	"TCP-STANDARD": {Ports: fmt.Sprintf("top-%d", len(topTCPPorts))},
+	"TCP-PROD":     {Ports: "1-65535", Rate: 100},
+	"TCP-PIPELINE": {Ports: "1-65535"},
+	"ICS":          {Ports: utils.Const_ICS_PORTS, Rate: 1, Workers: 1, NoBanners: true},
+}
+
+// Returns the native profile to use for a kind of port scan, or nil to run nmap.
+// The native engine is used when selected ("set engine native"), or when nmap is missing
+func nativeEngine(kind string) (*nativeProfile, error) {
+	if kind == "BANNERS" {
+		if !utils.IsDBAvailable() {
+			return nil, fmt.Errorf("database not available: no open ports to identify")
+		}
+		return &nativeProfile{Stored: true, Rate: utils.NATIVE_SCAN.Rate, Workers: utils.NATIVE_SCAN.Workers}, nil
+	}
+	engine := utils.ENGINE
+	if engine == utils.ENGINE_NMAP && !utils.IsCommandAvailable("nmap") {
+		utils.Config.Log.LogWarning("Nmap is not installed or not in PATH: falling back to the native TCP connect scanner")
+		engine = utils.ENGINE_NATIVE
+	}
+	if engine != utils.ENGINE_NATIVE {
+		return nil, nil
+	}
+	p, ok := nativeProfiles[kind]
+	if !ok {
+		return nil, fmt.Errorf("%s is not supported by the native engine (it requires nmap)", kind)
+	}
+	if utils.NATIVE_SCAN.Ports != "" {
+		p.Ports = utils.NATIVE_SCAN.Ports
+	}
+	if p.Rate == 0 || p.Rate > utils.NATIVE_SCAN.Rate {
+		p.Rate = utils.NATIVE_SCAN.Rate
+	}
+	if p.Workers == 0 || p.Workers > utils.NATIVE_SCAN.Workers {
+		p.Workers = utils.NATIVE_SCAN.Workers
+	}
+	if _, err := parsePortSpec(p.Ports); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Addresses of a target: an IP address, a hostname, or a CIDR (without its network and
+// broadcast addresses)
+func expandTarget(target string) ([]string, error) {
+	ip, network, err := net.ParseCIDR(target)
+	if err != nil {
+		return []string{target}, nil
+	}
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, fmt.Errorf("IPv6 ranges are not supported: %s", target)
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones > 16 {
+		return nil, fmt.Errorf("range too large (more than /16): %s", target)
+	}
+	first := binary.BigEndian.Uint32(network.IP.To4())
+	last := first | ^binary.BigEndian.Uint32(net.IP(network.Mask).To4())
+	if last-first > 1 {
+		first, last = first+1, last-1
+	}
+	addresses := []string{}
+	for n := first; n <= last && n >= first; n++ {
+		b := make(net.IP, 4)
+		binary.BigEndian.PutUint32(b, n)
+		addresses = append(addresses, b.String())
+	}
+	return addresses, nil
+}
+
+// Outcome of the connection attempts to a port
+const (
+	portOpen     = "open"
+	portClosed   = "closed"
+	portFiltered = "filtered"
+)
+
+func probeTCP(address string, port int, timeout time.Duration, retries int) string {
+	target := net.JoinHostPort(address, strconv.Itoa(port))
+	for attempt := 0; attempt <= retries; attempt++ {
+		conn, err := net.DialTimeout("tcp", target, timeout)
+		if err == nil {
+			conn.Close()
+			return portOpen
+		}
+		// A RST is an answer: no need to try again
+		if connRefused(err) {
+			return portClosed
+		}
+	}
+	return portFiltered
+}
+
+// Gate the probes on the scanning windows: paused while the window is closed (PAUSE),
+// stopped for good when it closes with CANCEL
+type nativeGate struct {
+	paused int32
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func (s *NmapScan) watchWindows() *nativeGate {
+	g := &nativeGate{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(windowPollDelay)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-g.done:
+				return
+			case <-ticker.C:
+			}
+			open, why, action := CheckWindow(time.Now())
+			switch {
+			case open:
+				if atomic.SwapInt32(&g.paused, 0) == 1 {
+					s.Status, s.Reason = model.IN_PROGRESS, ""
+				}
+			case action == model.WINDOW_ACTION_CANCEL:
+				s.Status, s.Reason = model.CANCELLED, "scanning window closed"
+				close(g.stop)
+				return
+			default:
+				atomic.StoreInt32(&g.paused, 1)
+				s.Status, s.Reason = model.WAITING, why
+			}
+		}
+	}()
+	return g
+}
+
+// Wait while paused, returns false if the scan has been stopped
+func (g *nativeGate) wait() bool {
+	for atomic.LoadInt32(&g.paused) == 1 {
+		select {
+		case <-g.stop:
+			return false
+		case <-time.After(time.Second):
+		}
+	}
+	select {
+	case <-g.stop:
+		return false
+	default:
+		return true
+	}
+}
+
+// Run the built-in TCP connect scanner (instead of nmap) on the target of the scan. The
+// results are saved as nmap XML (<outfile>.xml), so that they are parsed like nmap's
+func (s *NmapScan) RunNative(profile *nativeProfile) {
+	// Pre-scan checks
+	s.preScan()
+
+	s.runNative(profile)
+
+	// Post-scan checks
+	s.postScan()
+}
+
+// Run the native scanner, leaving the status IN_PROGRESS on success (the caller finishes
+// the job)
+func (s *NmapScan) runNative(profile *nativeProfile) {
+	addresses, err := expandTarget(s.Target)
+	if err != nil {
+		s.Status = model.FAILED
+		s.log().LogError(err.Error())
+		return
+	}
+	opts := utils.NATIVE_SCAN
+	ports := []int{}
+	if profile.Stored {
+		s.Cmd = fmt.Sprintf("banner-grab --rate %d --timeout %s %s", profile.Rate, opts.Timeout, s.Target)
+	} else {
+		if ports, err = parsePortSpec(profile.Ports); err != nil {
+			s.Status = model.FAILED
+			s.log().LogError(fmt.Sprintf("Invalid ports: %s", err))
+			return
+		}
+		s.Cmd = fmt.Sprintf("tcp-connect -p %s --rate %d --timeout %s --retries %d %s", profile.Ports, profile.Rate, opts.Timeout, opts.Retries, s.Target)
+	}
+
+	// Respect the scanning windows of the workspace
+	WaitForWindow(&s.Status, &s.Reason)
+	utils.ScanStartAnimation(s.Name, s.Target)
+	gate := s.watchWindows()
+	defer close(gate.done)
+
+	start := time.Now()
+	open := map[string][]int{}
+	counts := map[string]map[string]int{}
+	for _, address := range addresses {
+		counts[address] = map[string]int{}
+	}
+	if profile.Stored {
+		// Open TCP ports found by a previous scan
+		for _, address := range addresses {
+			h := model.GetHostByAddress(utils.Config.DB, address)
+			for _, p := range h.GetPorts(utils.Config.DB) {
+				if p.Status == portOpen && p.Protocol == "tcp" {
+					open[address] = append(open[address], p.Number)
+				}
+			}
+		}
+	} else {
+		s.log().LogDebug(fmt.Sprintf("Native scan: %d ports on %d addresses", len(ports), len(addresses)))
+		s.connectScan(gate, addresses, ports, profile, opts, open, counts)
+		if s.Status == model.CANCELLED {
+			utils.AuditProbe(s.job(), strings.Fields(s.Cmd), start, time.Now(), utils.ErrCancelled)
+			return
+		}
+		utils.AuditProbe(s.job(), strings.Fields(s.Cmd), start, time.Now(), nil)
+	}
+
+	// Identify the services of the open ports (not on fragile devices)
+	services := map[string]map[int]*bannerResult{}
+	if (opts.Banners || profile.Stored) && !profile.NoBanners {
+		var transcript strings.Builder
+		for _, address := range addresses {
+			grab := s.bannerPorts(address, open[address])
+			if len(grab) == 0 || !gate.wait() {
+				continue
+			}
+			grabStart := time.Now()
+			found, out := grabBanners(address, grab, opts, profile.Rate, profile.Workers)
+			argv := []string{"banner-grab", "-p", joinPorts(grab), address}
+			utils.AuditProbe(s.job(), argv, grabStart, time.Now(), nil)
+			services[address] = found
+			transcript.WriteString(out)
+		}
+		if transcript.Len() > 0 {
+			if err := ioutil.WriteFile(fmt.Sprintf("%s.banners", s.Outfile), []byte(transcript.String()), 0644); err != nil {
+				s.log().LogError(fmt.Sprintf("Cannot write output file: %s", err))
+			}
+		}
+	}
+	end := time.Now()
+
+	// Save the results as nmap XML
+	data, err := encodeReport(nativeReport(s.Cmd, addresses, open, counts, services, start, end))
+	if err == nil {
+		err = ioutil.WriteFile(fmt.Sprintf("%s.xml", s.Outfile), data, 0644)
+	}
+	if err != nil {
+		s.Status = model.FAILED
+		s.log().LogError(fmt.Sprintf("Cannot write output file: %s", err))
+	}
+}
+
+// Prefix of the details stored by the ICS enumeration (ics_vendor, ics_firmware, etc.)
+const icsDetailPrefix = "ics_"
+
+// Open ports of the address which can be probed for banners: the ICS ports, and every port
+// of the hosts identified as industrial devices, are skipped (they can crash when probed)
+func (s *NmapScan) bannerPorts(address string, ports []int) []int {
+	if len(ports) == 0 {
+		return nil
+	}
+	if utils.IsDBAvailable() {
+		h := model.GetHostByAddress(utils.Config.DB, address)
+		for _, d := range h.GetDetails(utils.Config.DB) {
+			if strings.HasPrefix(d.Key, icsDetailPrefix) {
+				s.log().LogWarning(fmt.Sprintf("Banner grabbing skipped on %s: identified as an industrial device", address))
+				return nil
+			}
+		}
+	}
+	ics, _ := parsePortSpec(utils.Const_ICS_PORTS)
+	fragile := map[int]bool{}
+	for _, port := range ics {
+		fragile[port] = true
+	}
+	grab := []int{}
+	for _, port := range ports {
+		if fragile[port] {
+			s.log().LogDebug(fmt.Sprintf("Banner grabbing skipped on %s:%d: ICS port", address, port))
+			continue
+		}
+		grab = append(grab, port)
+	}
+	return grab
+}
+
+// Connect to every port of every address (rate limited), recording the open ports and
+// the number of ports by state
+func (s *NmapScan) connectScan(gate *nativeGate, addresses []string, ports []int, profile *nativeProfile, opts utils.NativeScanOptions, open map[string][]int, counts map[string]map[string]int) {
+	type probe struct {
+		address string
+		port    int
+	}
+	type result struct {
+		probe
+		state string
+	}
+	limiter := time.NewTicker(time.Second / time.Duration(profile.Rate))
+	defer limiter.Stop()
+
+	probes := make(chan probe)
+	results := make(chan result)
+	go func() {
+		defer close(probes)
+		for _, port := range ports {
+			for _, address := range addresses {
+				select {
+				case probes <- probe{address, port}:
+				case <-gate.stop:
+					return
+				}
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < profile.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range probes {
+				if !gate.wait() {
+					return
+				}
+				select {
+				case <-limiter.C:
+				case <-gate.stop:
+					return
+				}
+				results <- result{p, probeTCP(p.address, p.port, opts.Timeout, opts.Retries)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		counts[r.address][r.state]++
+		if r.state == portOpen {
+			open[r.address] = append(open[r.address], r.port)
+		}
+	}
+}
+
+func joinPorts(ports []int) string {
+	list := []string{}
+	for _, p := range ports {
+		list = append(list, strconv.Itoa(p))
+	}
+	return strings.Join(list, ",")
+}
+
+// Encode a report as nmap XML
+func encodeReport(run *go_nmap.NmapRun) ([]byte, error) {
+	var data bytes.Buffer
+	data.WriteString(xml.Header)
+	enc := xml.NewEncoder(&data)
+	enc.Indent("", "  ")
+	if err := enc.EncodeElement(run, xml.StartElement{Name: xml.Name{Local: "nmaprun"}}); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// Results of a native scan in the format of nmap: open ports are listed (with the service
+// identified by its banner, or guessed from the port number), closed and filtered ports
+// are counted
+func nativeReport(args string, addresses []string, open map[string][]int, counts map[string]map[string]int, services map[string]map[int]*bannerResult, start, end time.Time) *go_nmap.NmapRun {
+	run := &go_nmap.NmapRun{
+		Scanner:  "goscan",
+		Args:     args,
+		Start:    go_nmap.Timestamp(start),
+		StartStr: start.Format(time.ANSIC),
+		ScanInfo: go_nmap.ScanInfo{Type: "connect", Protocol: "tcp"},
+	}
+	up := 0
+	for _, address := range addresses {
+		h := go_nmap.Host{
+			StartTime: go_nmap.Timestamp(start),
+			EndTime:   go_nmap.Timestamp(end),
+			Status:    go_nmap.Status{State: "down", Reason: "no-response"},
+			Addresses: []go_nmap.Address{{Addr: address, AddrType: "ipv4"}},
+		}
+		if net.ParseIP(address) == nil {
+			h.Hostnames = []go_nmap.Hostname{{Name: address, Type: "user"}}
+		} else if net.ParseIP(address).To4() == nil {
+			h.Addresses[0].AddrType = "ipv6"
+		}
+		if len(open[address]) > 0 || counts[address][portClosed] > 0 {
+			h.Status = go_nmap.Status{State: "up", Reason: "conn-refused"}
+			if len(open[address]) > 0 {
+				h.Status.Reason = "syn-ack"
+			}
+			up++
+		}
+		for _, port := range open[address] {
+			service := go_nmap.Service{Name: tcpServiceNames[port], Method: "table", Conf: 3}
+			if r, ok := services[address][port]; ok {
+				service = go_nmap.Service{Name: r.Service, Product: r.Product, Version: r.Version, ExtraInfo: r.Info, Tunnel: r.Tunnel, Method: "probed", Conf: 8}
+				if service.Name == "" {
+					service.Name = tcpServiceNames[port]
+				}
+			}
+			h.Ports = append(h.Ports, go_nmap.Port{
+				Protocol: "tcp",
+				PortId:   port,
+				State:    go_nmap.State{State: portOpen, Reason: "syn-ack"},
+				Service:  service,
+			})
+		}
+		for _, state := range []string{portClosed, portFiltered} {
+			if n := counts[address][state]; n > 0 {
+				h.ExtraPorts = append(h.ExtraPorts, go_nmap.ExtraPorts{State: state, Count: n})
+			}
+		}
+		run.Hosts = append(run.Hosts, h)
+	}
+	run.RunStats = go_nmap.RunStats{
+		Finished: go_nmap.Finished{
+			Time:    go_nmap.Timestamp(end),
+			TimeStr: end.Format(time.ANSIC),
+			Elapsed: float32(end.Sub(start).Seconds()),
+			Exit:    "success",
+			Summary: fmt.Sprintf("%d IP addresses (%d hosts up) scanned in %.2f seconds", len(addresses), up, end.Sub(start).Seconds()),
+		},
+		Hosts: go_nmap.HostStats{Up: up, Down: len(addresses) - up, Total: len(addresses)},
+	}
+	return run
+}
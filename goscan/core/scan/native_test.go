@@ -0,0 +1,133 @@
+package scan
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	go_nmap "github.com/lair-framework/go-nmap"
+)
+
+// Port listening on loopback, closed at the end of the test
+func openPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// Port that was just released, nothing listens on it
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestParsePortSpec(t *testing.T) {
+	cases := []struct {
+		spec  string
+		ports []int
+	}{
+		{"22,80,8000-8002", []int{22, 80, 8000, 8001, 8002}},
+		{"80, 22,80", []int{80, 22}},
+		{"T:22,443,U:53,161", []int{22, 443}},
+		{"top-3", topTCPPorts[:3]},
+		{"top-100000", topTCPPorts},
+	}
+	for _, c := range cases {
+		ports, err := parsePortSpec(c.spec)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(ports, c.ports) {
+			t.Errorf("%s: got %v, want %v", c.spec, ports, c.ports)
+		}
+	}
+	if ports, _ := parsePortSpec("-"); len(ports) != 65535 {
+		t.Errorf("-: got %d ports, want 65535", len(ports))
+	}
+	for _, spec := range []string{"", "http", "0", "70000", "90-80", "top-0", "U:53"} {
+		if _, err := parsePortSpec(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	open, closed := openPort(t), closedPort(t)
+	if state := probeTCP("127.0.0.1", open, time.Second, 0); state != portOpen {
+		t.Errorf("listening port: got %s, want %s", state, portOpen)
+	}
+	if state := probeTCP("127.0.0.1", closed, time.Second, 1); state != portClosed {
+		t.Errorf("closed port: got %s, want %s", state, portClosed)
+	}
+}
+
+func TestNativeReport(t *testing.T) {
+	open, closed := openPort(t), closedPort(t)
+	addresses := []string{"127.0.0.1"}
+	found := map[string][]int{}
+	counts := map[string]map[string]int{"127.0.0.1": {}}
+	for _, port := range []int{open, closed} {
+		state := probeTCP("127.0.0.1", port, time.Second, 0)
+		counts["127.0.0.1"][state]++
+		if state == portOpen {
+			found["127.0.0.1"] = append(found["127.0.0.1"], port)
+		}
+	}
+	services := map[string]map[int]*bannerResult{
+		"127.0.0.1": {open: {Service: "ssh", Product: "OpenSSH", Version: "8.4p1"}},
+	}
+
+	start := time.Now()
+	data, err := encodeReport(nativeReport("tcp-connect 127.0.0.1", addresses, found, counts, services, start, start.Add(time.Second)))
+	if err != nil {
+		t.Fatalf("cannot encode the report: %s", err)
+	}
+	run, err := go_nmap.Parse(data)
+	if err != nil {
+		t.Fatalf("report is not valid nmap XML: %s\n%s", err, data)
+	}
+	if len(run.Hosts) != 1 {
+		t.Fatalf("got %d hosts, want 1", len(run.Hosts))
+	}
+	h := run.Hosts[0]
+	if h.Status.State != "up" || h.Addresses[0].Addr != "127.0.0.1" {
+		t.Errorf("host: got %s %v, want 127.0.0.1 up", h.Status.State, h.Addresses)
+	}
+	if len(h.Ports) != 1 {
+		t.Fatalf("got %d ports, want the open one only", len(h.Ports))
+	}
+	p := h.Ports[0]
+	if p.PortId != open || p.Protocol != "tcp" || p.State.State != portOpen {
+		t.Errorf("port: got %d/%s %s, want %d/tcp open", p.PortId, p.Protocol, p.State.State, open)
+	}
+	if p.Service.Name != "ssh" || p.Service.Product != "OpenSSH" || p.Service.Version != "8.4p1" {
+		t.Errorf("service: got %+v", p.Service)
+	}
+	if len(h.ExtraPorts) != 1 || h.ExtraPorts[0].State != portClosed || h.ExtraPorts[0].Count != 1 {
+		t.Errorf("extra ports: got %+v, want 1 closed", h.ExtraPorts)
+	}
+	if run.RunStats.Hosts.Up != 1 || run.RunStats.Hosts.Total != 1 {
+		t.Errorf("stats: got %+v", run.RunStats.Hosts)
+	}
+}
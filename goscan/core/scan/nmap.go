@@ -20,9 +20,11 @@ type NmapScan model.Scan
 func NewScan(name, target, folder, file, nmapArgs string) *NmapScan {
 	// Create a Scan
 	s := &NmapScan{
+		ID:     model.NextJobID(),
 		Name:   name,
 		Target: target,
 		Status: model.NOT_STARTED,
+		Origin: utils.CurrentOrigin(),
 	}
 	// Construct output path and create if it doesn't exist
 	s.Outfolder = filepath.Join(utils.Config.Outfolder, utils.CleanPath(target), folder)
@@ -37,7 +39,19 @@ func (s *NmapScan) preScan() {
 	s.Status = model.IN_PROGRESS
 }
 func (s *NmapScan) postScan() {
-	s.Status = model.FINISHED
+	if s.Status == model.IN_PROGRESS {
+		s.Status = model.FINISHED
+	}
+}
+
+// Context used to audit the commands run by the scan
+func (s *NmapScan) job() utils.Job {
+	return utils.Job{ID: s.ID, Kind: s.Name, Target: s.Target, Origin: s.Origin}
+}
+
+// Logger adding the fields of the scan to every record
+func (s *NmapScan) log() *utils.Logger {
+	return utils.Config.Log.WithJob(s.job())
 }
 
 func (s *NmapScan) constructCmd(args string) string {
@@ -49,14 +63,25 @@ func (s *NmapScan) RunNmap() {
 	// Pre-scan checks
 	s.preScan()
 
+	s.runNmap()
+
+	// Post-scan checks
+	s.postScan()
+}
+
+// Run nmap, leaving the status IN_PROGRESS on success (the caller finishes the job)
+func (s *NmapScan) runNmap() {
 	// Ensure required dependency is available
 	if !utils.IsCommandAvailable("nmap") {
 		s.Status = model.FAILED
-		utils.Config.Log.LogError("Nmap is not installed or not in PATH. Please install Nmap (https://nmap.org/download.html) and ensure it's accessible.")
+		s.log().LogError("Nmap is not installed or not in PATH. Please install Nmap (https://nmap.org/download.html) and ensure it's accessible.")
 		utils.ScanFailedAnimation(s.Name, s.Target, "Nmap not found in PATH")
 		return
 	}
 
+	// Respect the scanning windows of the workspace
+	WaitForWindow(&s.Status, &s.Reason)
+
 	// Show scan start animation
 	utils.ScanStartAnimation(s.Name, s.Target)
 
@@ -64,14 +89,14 @@ func (s *NmapScan) RunNmap() {
 	utils.LoadingSpinner(fmt.Sprintf("Executing %s on %s", s.Name, s.Target), 2*time.Second)
 
 	// Run nmap
-	_, err := utils.ShellCmd(s.Cmd)
-	if err != nil {
+	_, err := utils.ShellCmdJob(s.job(), s.Cmd)
+	if err == utils.ErrCancelled {
+		s.Status = model.CANCELLED
+		s.Reason = "scanning window closed"
+	} else if err != nil {
 		s.Status = model.FAILED
 		utils.ScanFailedAnimation(s.Name, s.Target, err.Error())
 	}
-
-	// Post-scan checks
-	s.postScan()
 }
 
 // Parse nmap XML output file
@@ -79,14 +104,14 @@ func (s *NmapScan) ParseOutput() *go_nmap.NmapRun {
 	sweepXML := fmt.Sprintf("%s.xml", s.Outfile)
 	dat, err := ioutil.ReadFile(sweepXML)
 	if err != nil {
-		utils.Config.Log.LogError(fmt.Sprintf("Error while opening output file: %s", sweepXML))
+		s.log().LogError(fmt.Sprintf("Error while opening output file: %s", sweepXML))
 		utils.ScanFailedAnimation(s.Name, s.Target, "Output file not found")
 		return nil
 	}
 
 	res, err := go_nmap.Parse(dat)
 	if err != nil {
-		utils.Config.Log.LogError("Error while parsing nmap output")
+		s.log().LogError("Error while parsing nmap output")
 		utils.ScanFailedAnimation(s.Name, s.Target, "Failed to parse output")
 		return nil
 	}
@@ -132,15 +157,21 @@ func ReportStatusNmap() {
 				case scan.Status == model.NULL:
 					break
 				case scan.Status == model.FAILED:
-					utils.Config.Log.LogError(fmt.Sprintf("Nmap failed on host: %s", scan.Target))
+					scan.log().LogError(fmt.Sprintf("Nmap failed on host: %s", scan.Target))
+				case scan.Status == model.CANCELLED:
+					scan.log().LogWarning(fmt.Sprintf("[%s] Nmap cancelled on host:\t%s (%s)", scan.Name, scan.Target, scan.Reason))
+				case scan.Status == model.WAITING:
+					scan.log().LogInfo(fmt.Sprintf("[%s] Nmap waiting on host:\t%s (%s)", scan.Name, scan.Target, scan.Reason))
+					ScansList[i] = scan
+					i++
 				case scan.Status == model.IN_PROGRESS:
-					utils.Config.Log.LogInfo(fmt.Sprintf("[%s] Nmap work in progress on host:\t%s", scan.Name, scan.Target))
+					scan.log().LogInfo(fmt.Sprintf("[%s] Nmap work in progress on host:\t%s", scan.Name, scan.Target))
 					// Update in place, remove finished scans
 					ScansList[i] = scan
 					i++
 				case scan.Status == model.FINISHED:
-					utils.Config.Log.LogNotify(fmt.Sprintf("[%s] Nmap finished on host:\t%s", scan.Name, scan.Target))
-					utils.Config.Log.LogNotify(fmt.Sprintf("[%s] Output has been saved at:\t%s", scan.Name, utils.Config.Outfolder))
+					scan.log().LogNotify(fmt.Sprintf("[%s] Nmap finished on host:\t%s", scan.Name, scan.Target))
+					scan.log().LogNotify(fmt.Sprintf("[%s] Output has been saved at:\t%s", scan.Name, utils.Config.Outfolder))
 				}
 			}
 			// Update in place, remove finished scans
@@ -0,0 +1,387 @@
+package scan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// NATIVE PING SWEEP
+// ---------------------------------------------------------------------------------------
+// Built-in host discovery (no nmap): ICMP echo requests, then TCP connect probes to a few
+// common ports for the hosts that did not answer (a SYN-ACK or a RST both mean that the
+// host is up), then the ARP entries resolved during the sweep for the hosts of the local
+// subnets
+const (
+	sweepICMP = "icmp"
+	sweepTCP  = "tcp"
+	sweepARP  = "arp"
+)
+
+const (
+	SWEEP_PROBE = "sweep_probe"
+	SWEEP_MAC   = "sweep_mac"
+)
+
+// Live host, with the probe that found it first ("icmp", "tcp/<port>" or "arp")
+type sweepHit struct {
+	Probe string
+	RTT   time.Duration
+	MAC   string
+}
+
+// Run the native ping sweep on the target of the scan: live hosts are stored directly,
+// and listed in <outfile>.txt
+func (s *NmapScan) RunPingSweep() {
+	// Pre-scan checks
+	s.preScan()
+	opts := utils.NATIVE_SCAN
+	ports, err := parsePortSpec(opts.SweepPorts)
+	if err != nil {
+		s.Status = model.FAILED
+		s.log().LogError(fmt.Sprintf("Invalid sweep ports: %s", err))
+		return
+	}
+	addresses, err := s.sweepAddresses()
+	if err != nil {
+		s.Status = model.FAILED
+		s.log().LogError(err.Error())
+		return
+	}
+	s.Cmd = fmt.Sprintf("ping-sweep --icmp --tcp %s --arp --rate %d --timeout %s --retries %d %s", opts.SweepPorts, opts.Rate, opts.Timeout, opts.Retries, s.Target)
+
+	// Respect the scanning windows of the workspace
+	WaitForWindow(&s.Status, &s.Reason)
+	utils.ScanStartAnimation(s.Name, s.Target)
+	gate := s.watchWindows()
+	defer close(gate.done)
+
+	// ARP entries already there before the probes (they may be stale)
+	before, _ := arpTable()
+
+	start := time.Now()
+	hits := map[string]*sweepHit{}
+	var mu sync.Mutex
+	found := func(address, probe string, rtt time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := hits[address]; !ok {
+			hits[address] = &sweepHit{Probe: probe, RTT: rtt}
+		}
+	}
+	if err := pingICMP(gate, addresses, opts, found); err != nil {
+		s.log().LogWarning(fmt.Sprintf("ICMP echo not available (requires root, or a group in net.ipv4.ping_group_range): %s", err))
+	}
+	pending := []string{}
+	for _, address := range addresses {
+		if _, ok := hits[address]; !ok {
+			pending = append(pending, address)
+		}
+	}
+	pingTCP(gate, pending, ports, opts, found)
+	if s.Status == model.CANCELLED {
+		utils.AuditProbe(s.job(), strings.Fields(s.Cmd), start, time.Now(), utils.ErrCancelled)
+		return
+	}
+	if table, err := arpTable(); err != nil {
+		s.log().LogDebug(fmt.Sprintf("ARP table not available: %s", err))
+	} else {
+		for _, address := range addresses {
+			mac, ok := table[address]
+			if !ok {
+				continue
+			}
+			// Live hosts get their MAC address, the others are up only if resolved now
+			if _, up := hits[address]; !up && before[address] == mac {
+				continue
+			}
+			found(address, sweepARP, 0)
+			hits[address].MAC = mac
+		}
+	}
+	utils.AuditProbe(s.job(), strings.Fields(s.Cmd), start, time.Now(), nil)
+
+	s.storeSweep(addresses, hits)
+
+	// Post-scan checks
+	s.postScan()
+}
+
+// Addresses of the target (hostnames are resolved, the probes need an IP address)
+func (s *NmapScan) sweepAddresses() ([]string, error) {
+	targets, err := expandTarget(s.Target)
+	if err != nil {
+		return nil, err
+	}
+	addresses := []string{}
+	for _, t := range targets {
+		if net.ParseIP(t) != nil {
+			addresses = append(addresses, t)
+			continue
+		}
+		ips, err := net.LookupIP(t)
+		if err != nil || len(ips) == 0 {
+			s.log().LogWarning(fmt.Sprintf("Cannot resolve %s: %s", t, err))
+			continue
+		}
+		ip := ips[0]
+		for _, candidate := range ips {
+			if candidate.To4() != nil {
+				ip = candidate
+				break
+			}
+		}
+		addresses = append(addresses, ip.String())
+	}
+	return addresses, nil
+}
+
+// Store the live hosts and the statistics of the probes that found them
+func (s *NmapScan) storeSweep(addresses []string, hits map[string]*sweepHit) {
+	stats := map[string]int{}
+	var report strings.Builder
+	fmt.Fprintf(&report, "# %s\n# address\tprobe\trtt\tmac\n", s.Cmd)
+	for _, address := range addresses {
+		hit, ok := hits[address]
+		if !ok {
+			continue
+		}
+		stats[strings.SplitN(hit.Probe, "/", 2)[0]]++
+		rtt := "-"
+		if hit.RTT > 0 {
+			rtt = hit.RTT.Round(time.Microsecond).String()
+		}
+		fmt.Fprintf(&report, "%s\t%s\t%s\t%s\n", address, hit.Probe, rtt, hit.MAC)
+		s.log().LogDebug(fmt.Sprintf("Host up: %s (%s, %s)", address, hit.Probe, rtt))
+
+		if !utils.IsDBAvailable() {
+			utils.Config.Log.LogInfo(fmt.Sprintf("Host discovered: %s (not persisted - DB disabled)", address))
+			continue
+		}
+		model.AddHost(utils.Config.DB, address, "up", model.NEW.String())
+		h := model.GetHostByAddress(utils.Config.DB, address)
+		model.AddDetail(utils.Config.DB, h, 0, "SWEEP", SWEEP_PROBE, hit.Probe)
+		if hit.MAC != "" {
+			model.AddDetail(utils.Config.DB, h, 0, "SWEEP", SWEEP_MAC, hit.MAC)
+		}
+	}
+	if err := ioutil.WriteFile(fmt.Sprintf("%s.txt", s.Outfile), []byte(report.String()), 0644); err != nil {
+		s.log().LogError(fmt.Sprintf("Cannot write output file: %s", err))
+	}
+	s.log().LogInfo(fmt.Sprintf("Native ping sweep of %s: %d/%d hosts up (ICMP echo: %d, TCP: %d, ARP: %d)",
+		s.Target, len(hits), len(addresses), stats[sweepICMP], stats[sweepTCP], stats[sweepARP]))
+}
+
+// ---------------------------------------------------------------------------------------
+// PROBES
+// ---------------------------------------------------------------------------------------
+// ICMP socket: raw when privileged, otherwise the unprivileged datagram one (the kernel
+// then picks the identifier of the requests, and only delivers the matching replies)
+func listenICMP() (net.PacketConn, bool, error) {
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err == nil {
+		return conn, true, nil
+	}
+	conn, dgramErr := listenICMPDatagram()
+	if dgramErr != nil {
+		return nil, false, fmt.Errorf("%s; %s", err, dgramErr)
+	}
+	return conn, false, nil
+}
+
+func echoRequest(id, seq int) []byte {
+	b := append(make([]byte, 8), "goscan"...)
+	b[0] = 8 // echo request, code 0
+	binary.BigEndian.PutUint16(b[4:], uint16(id))
+	binary.BigEndian.PutUint16(b[6:], uint16(seq))
+	binary.BigEndian.PutUint16(b[2:], icmpChecksum(b))
+	return b
+}
+
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// Send echo requests to the IPv4 addresses (rate limited, retrying the silent ones),
+// reporting the addresses that replied
+func pingICMP(gate *nativeGate, addresses []string, opts utils.NativeScanOptions, found func(string, string, time.Duration)) error {
+	conn, raw, err := listenICMP()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	id := os.Getpid() & 0xffff
+
+	var mu sync.Mutex
+	sent := map[string]time.Time{}
+	replied := map[string]bool{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1500)
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			// Echo replies only (raw sockets get every ICMP message of the host)
+			if n < 8 || buf[0] != 0 || buf[1] != 0 {
+				continue
+			}
+			if raw && int(binary.BigEndian.Uint16(buf[4:6])) != id {
+				continue
+			}
+			var address string
+			switch a := peer.(type) {
+			case *net.IPAddr:
+				address = a.IP.String()
+			case *net.UDPAddr:
+				address = a.IP.String()
+			}
+			mu.Lock()
+			if t, ok := sent[address]; ok && !replied[address] {
+				replied[address] = true
+				found(address, sweepICMP, time.Since(t))
+			}
+			mu.Unlock()
+		}
+	}()
+
+	limiter := time.NewTicker(time.Second / time.Duration(opts.Rate))
+	defer limiter.Stop()
+	seq := 0
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		pending := 0
+		for _, address := range addresses {
+			ip := net.ParseIP(address).To4()
+			mu.Lock()
+			skip := ip == nil || replied[address]
+			mu.Unlock()
+			if skip {
+				continue
+			}
+			stopped := !gate.wait()
+			if !stopped {
+				select {
+				case <-limiter.C:
+				case <-gate.stop:
+					stopped = true
+				}
+			}
+			if stopped {
+				break
+			}
+			var dst net.Addr = &net.IPAddr{IP: ip}
+			if !raw {
+				dst = &net.UDPAddr{IP: ip}
+			}
+			seq++
+			mu.Lock()
+			sent[address] = time.Now()
+			mu.Unlock()
+			// Unreachable destinations are just silent hosts
+			conn.WriteTo(echoRequest(id, seq), dst)
+			pending++
+		}
+		if pending == 0 {
+			break
+		}
+		// Wait for the replies
+		select {
+		case <-time.After(opts.Timeout):
+		case <-gate.stop:
+		}
+	}
+	conn.SetReadDeadline(time.Now())
+	<-done
+	return nil
+}
+
+// Connect to the ports of each address until one answers (at most workers addresses at
+// a time, within the rate of the scanner)
+func pingTCP(gate *nativeGate, addresses []string, ports []int, opts utils.NativeScanOptions, found func(string, string, time.Duration)) {
+	limiter := time.NewTicker(time.Second / time.Duration(opts.Rate))
+	defer limiter.Stop()
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, address := range addresses {
+			select {
+			case jobs <- address:
+			case <-gate.stop:
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers && i < len(addresses); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range jobs {
+				for _, port := range ports {
+					if !gate.wait() {
+						return
+					}
+					select {
+					case <-limiter.C:
+					case <-gate.stop:
+						return
+					}
+					start := time.Now()
+					if probeTCP(address, port, opts.Timeout, 0) != portFiltered {
+						found(address, fmt.Sprintf("%s/%d", sweepTCP, port), time.Since(start))
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Resolved neighbours of the ARP table (Linux), by IP address. The ICMP and TCP probes
+// of the addresses of a local subnet trigger ARP requests first, so the hosts dropping
+// both still show up as long as they answer ARP. The table also keeps entries of hosts
+// which are gone, so only the entries added (or changed) during the sweep are trusted
+func arpTable() (map[string]string, error) {
+	data, err := ioutil.ReadFile("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	table := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[2], 0, 32)
+		if err != nil || flags&0x2 == 0 || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		table[fields[0]] = fields[3]
+	}
+	return table, nil
+}
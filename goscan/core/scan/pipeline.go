@@ -0,0 +1,91 @@
+package scan
+
+import (
+	"fmt"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// TCP PIPELINE
+// ---------------------------------------------------------------------------------------
+// Two-phase port scan: the open ports of the whole range are found first (nmap with a
+// minimum rate, or the native engine), then version detection and default scripts run on
+// these ports only, instead of on every port like TCP-FULL
+const pipelineScan = "tcp_pipeline"
+
+// Run both phases as a single job, with outputs in <outfile>_discovery.* and
+// <outfile>_services.*. The results of the first phase are stored straight away, the
+// second phase then updates the services of the ports
+func (s *NmapScan) RunPipeline(h *model.Host, native *nativeProfile) {
+	// Pre-scan checks, the job is finished once both phases are done
+	s.preScan()
+	defer s.postScan()
+	base := s.Outfile
+
+	// Phase 1: open ports
+	s.Outfile = fmt.Sprintf("%s_discovery", base)
+	if native != nil {
+		s.runNative(native)
+	} else {
+		s.Cmd = s.constructCmd(utils.Const_NMAP_TCP_DISCOVERY)
+		s.runNmap()
+	}
+	if s.Status != model.IN_PROGRESS {
+		return
+	}
+	res := ParseOutput(fmt.Sprintf("%s.xml", s.Outfile))
+	if res == nil {
+		s.Status = model.FAILED
+		return
+	}
+	addresses := []string{}
+	open := map[string][]int{}
+	count := 0
+	for _, record := range res.Hosts {
+		ProcessResults(h, record)
+		if len(record.Addresses) == 0 {
+			continue
+		}
+		address := record.Addresses[0].Addr
+		for _, port := range record.Ports {
+			if port.Protocol != "tcp" || port.State.State != portOpen {
+				continue
+			}
+			if _, ok := open[address]; !ok {
+				addresses = append(addresses, address)
+			}
+			open[address] = append(open[address], port.PortId)
+			count++
+		}
+	}
+	s.log().LogInfo(fmt.Sprintf("Pipeline on %s: %d open TCP ports on %d hosts", s.Target, count, len(addresses)))
+	if len(addresses) == 0 {
+		utils.ScanCompleteAnimation(s.Name, s.Target, len(res.Hosts))
+		return
+	}
+	if !utils.IsCommandAvailable("nmap") {
+		s.log().LogWarning("Version detection skipped: nmap is not installed or not in PATH (services identified by banner grabbing only)")
+		utils.ScanCompleteAnimation(s.Name, s.Target, len(res.Hosts))
+		return
+	}
+
+	// Phase 2: version detection and default scripts, per host, on its open ports only
+	for _, address := range addresses {
+		s.Outfile = fmt.Sprintf("%s_services", base)
+		if len(addresses) > 1 {
+			s.Outfile = fmt.Sprintf("%s_services_%s", base, utils.CleanPath(address))
+		}
+		s.Cmd = fmt.Sprintf("nmap %s -p%s %s -oA \"%s\"", utils.Const_NMAP_TCP_SERVICES, joinPorts(open[address]), address, s.Outfile)
+		s.runNmap()
+		if s.Status != model.IN_PROGRESS {
+			return
+		}
+		if res := s.ParseOutput(); res != nil {
+			for _, record := range res.Hosts {
+				ProcessResults(h, record)
+			}
+		}
+	}
+}
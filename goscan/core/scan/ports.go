@@ -0,0 +1,113 @@
+package scan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------------------
+// PORT TABLES
+// ---------------------------------------------------------------------------------------
+// Most common TCP ports, by frequency (nmap's ranking), followed by the ports of the
+// enumeration recipes. This is not nmap's top-1000 table: "top-N" takes the first N
+// ports, and at most the whole ranking
+var topTCPPorts = []int{
+	80, 23, 443, 21, 22, 25, 3389, 110, 445, 139, 143, 53, 135, 3306, 8080, 1723, 111, 995, 993, 5900,
+	1025, 587, 8888, 199, 1720, 465, 548, 113, 81, 6001, 10000, 514, 5060, 179, 1026, 2000, 8443, 8000, 32768, 554,
+	26, 1433, 49152, 2001, 515, 8008, 49154, 1027, 5666, 646, 5000, 5631, 631, 49153, 8081, 2049, 88, 79, 5800, 106,
+	2121, 1110, 49155, 6000, 513, 990, 5357, 427, 49156, 543, 544, 5101, 144, 7, 389, 8009, 3128, 444, 9999, 5009,
+	7070, 5190, 3000, 5432, 1900, 3986, 13, 1029, 9, 5051, 6646, 49157, 1028, 873, 1755, 2717, 4899, 9100, 119, 37,
+	// Recipes (LDAP, Kerberos, databases, containers, ICS, remote management)
+	636, 3268, 3269, 464, 749, 1521, 2375, 2376, 2379, 5984, 6379, 6443, 9042, 9200, 9300, 10250, 10255, 11211, 27017, 5985,
+	5986, 5061, 5901, 6002, 102, 502, 20000, 44818, 161, 162, 69, 123, 137, 138, 512, 1434, 2380, 5001, 7001, 8880,
+	8983, 9090, 9443, 15672, 25565, 50000,
+}
+
+// Service names guessed from the port number (nmap-services names, so that the
+// enumeration recipes matching on service names still apply)
+var tcpServiceNames = map[int]string{
+	7: "echo", 9: "discard", 13: "daytime", 21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 26: "rsftp",
+	37: "time", 53: "domain", 79: "finger", 80: "http", 81: "hosts2-ns", 88: "kerberos-sec", 102: "iso-tsap",
+	106: "pop3pw", 110: "pop3", 111: "rpcbind", 113: "ident", 119: "nntp", 135: "msrpc", 139: "netbios-ssn",
+	143: "imap", 161: "snmp", 179: "bgp", 199: "smux", 389: "ldap", 427: "svrloc", 443: "https", 445: "microsoft-ds",
+	464: "kpasswd5", 465: "smtps", 502: "mbap", 512: "exec", 513: "login", 514: "shell", 515: "printer",
+	548: "afp", 554: "rtsp", 587: "submission", 631: "ipp", 636: "ldapssl", 873: "rsync", 990: "ftps",
+	993: "imaps", 995: "pop3s", 1433: "ms-sql-s", 1521: "oracle", 1723: "pptp", 1900: "upnp", 2049: "nfs",
+	2121: "ccproxy-ftp", 2375: "docker", 2376: "docker", 2379: "etcd-client", 2380: "etcd-server", 3128: "squid-http",
+	3268: "globalcatLDAP", 3269: "globalcatLDAPssl", 3306: "mysql", 3389: "ms-wbt-server", 5000: "upnp",
+	5060: "sip", 5061: "sip-tls", 5432: "postgresql", 5800: "vnc-http", 5900: "vnc", 5901: "vnc-1", 5984: "couchdb",
+	5985: "wsman", 5986: "wsmans", 6000: "X11", 6001: "X11:1", 6002: "X11:2", 6379: "redis", 6443: "sun-sr-https",
+	8000: "http-alt", 8008: "http", 8080: "http-proxy", 8081: "blackice-icecap", 8443: "https-alt",
+	8888: "sun-answerbook", 9042: "cassandra", 9100: "jetdirect", 9200: "wap-wsp", 10000: "snet-sensor-mgmt",
+	10250: "kubelet", 10255: "kubelet", 11211: "memcache", 20000: "dnp", 27017: "mongod", 44818: "EtherNetIP-2",
+}
+
+// Returns the first n ports of the ranking (capped at its length)
+func topPorts(n int) []int {
+	if n > len(topTCPPorts) {
+		n = len(topTCPPorts)
+	}
+	return append([]int{}, topTCPPorts[:n]...)
+}
+
+// Parse a TCP port specification: comma-separated ports and ranges ("22,80,8000-8100",
+// "-" for all the ports), "top-N" for the most common ones (see topTCPPorts). nmap's
+// "T:" prefix is accepted, UDP ports ("U:") are skipped
+func parsePortSpec(spec string) ([]int, error) {
+	ports := []int{}
+	seen := map[int]bool{}
+	add := func(p int) {
+		if !seen[p] {
+			seen[p] = true
+			ports = append(ports, p)
+		}
+	}
+	udp := false
+	for _, token := range strings.Split(spec, ",") {
+		token = strings.TrimSpace(token)
+		switch {
+		case strings.HasPrefix(token, "T:"):
+			udp, token = false, token[2:]
+		case strings.HasPrefix(token, "U:"):
+			udp = true
+		}
+		if udp || token == "" {
+			continue
+		}
+		if strings.HasPrefix(token, "top-") {
+			n, err := strconv.Atoi(token[4:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid number of top ports: %s", token)
+			}
+			for _, p := range topPorts(n) {
+				add(p)
+			}
+			continue
+		}
+		if token == "-" {
+			token = "1-65535"
+		}
+		bounds := strings.SplitN(token, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", token)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid port range: %s", token)
+			}
+		}
+		if first < 1 || last > 65535 || first > last {
+			return nil, fmt.Errorf("invalid port range: %s", token)
+		}
+		for p := first; p <= last; p++ {
+			add(p)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no TCP ports in: %s", spec)
+	}
+	return ports, nil
+}
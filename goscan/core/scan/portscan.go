@@ -20,48 +20,64 @@ var ScansList = []*NmapScan{}
 // ---------------------------------------------------------------------------------------
 func ScanPort(kind string, target string) {
 	folder := "portscan"
+	var file, nmapArgs string
 
 	// Dispatch scan
 	switch kind {
 	case "TCP-FULL":
 		utils.Config.Log.LogInfo("Starting full TCP port scan")
-		file, nmapArgs := "tcp_full", utils.Const_NMAP_TCP_FULL
-		execScan(file, target, folder, file, nmapArgs)
+		file, nmapArgs = "tcp_full", utils.Const_NMAP_TCP_FULL
 	case "TCP-STANDARD":
 		utils.Config.Log.LogInfo("Starting top 200 TCP port scan")
-		file, nmapArgs := "tcp_standard", utils.Const_NMAP_TCP_STANDARD
-		execScan(file, target, folder, file, nmapArgs)
+		file, nmapArgs = "tcp_standard", utils.Const_NMAP_TCP_STANDARD
 	case "TCP-PROD":
 		utils.Config.Log.LogInfo("Starting production TCP port scan")
-		file, nmapArgs := "tcp_prod", utils.Const_NMAP_TCP_PROD
-		execScan(file, target, folder, file, nmapArgs)
+		file, nmapArgs = "tcp_prod", utils.Const_NMAP_TCP_PROD
+	case "TCP-PIPELINE":
+		utils.Config.Log.LogInfo("Starting TCP pipeline scan (open ports discovery, then version detection)")
+		file, nmapArgs = pipelineScan, utils.Const_NMAP_TCP_DISCOVERY
 	case "TCP-VULN-SCAN":
 		utils.Config.Log.LogInfo("Starting TCP vuln scan")
-		file, nmapArgs := "tcp_vuln", utils.Const_NMAP_TCP_VULN
-		execScan(file, target, folder, file, nmapArgs)
+		file, nmapArgs = "tcp_vuln", utils.Const_NMAP_TCP_VULN
 	case "UDP-STANDARD":
 		utils.Config.Log.LogInfo("Starting UDP port scan (common ports)")
-		file, nmapArgs := "udp_standard", utils.Const_NMAP_UDP_STANDARD
-		execScan(file, target, folder, file, nmapArgs)
+		file, nmapArgs = "udp_standard", utils.Const_NMAP_UDP_STANDARD
 	case "UDP-PROD":
 		utils.Config.Log.LogInfo("Starting production UDP port scan (common ports)")
-		file, nmapArgs := "udp_prod", utils.Const_NMAP_UDP_PROD
-		execScan(file, target, folder, file, nmapArgs)
+		file, nmapArgs = "udp_prod", utils.Const_NMAP_UDP_PROD
+	case "ICS":
+		utils.WarningBanner("ICS / OT DEVICES", utils.Const_ICS_WARNING)
+		utils.Config.Log.LogWarning("Starting ICS port scan (fragile devices: slow, connect-only probes)")
+		file, nmapArgs = "ics", utils.Const_NMAP_ICS
+	case "BANNERS":
+		utils.Config.Log.LogInfo("Starting banner grabbing of the open TCP ports")
+		file = "banners"
 	default:
 		utils.Config.Log.LogError("Invalid type of scan")
 		return
 	}
+
+	// Select the scan engine
+	native, err := nativeEngine(kind)
+	if err != nil {
+		utils.Config.Log.LogError(err.Error())
+		return
+	}
+	execScan(file, target, folder, file, nmapArgs, native)
 }
 
 // ---------------------------------------------------------------------------------------
 // SCAN LAUNCHER
 // ---------------------------------------------------------------------------------------
-func execScan(name, target, folder, file, nmapArgs string) {
+func execScan(name, target, folder, file, nmapArgs string, native *nativeProfile) {
+	// Jobs are started asynchronously, keep track of the command that originated them
+	origin := utils.CurrentOrigin()
+
 	// If no database is available, run directly against the provided target
 	if !utils.IsDBAvailable() {
 		temp := model.Host{Address: target, Step: model.NEW.String()}
 		fname := fmt.Sprintf("%s_%s", file, target)
-		go worker(name, &temp, folder, fname, nmapArgs)
+		go worker(name, &temp, folder, fname, nmapArgs, native, origin)
 		return
 	}
 
@@ -76,7 +92,7 @@ func execScan(name, target, folder, file, nmapArgs string) {
 			target == h.Address {
 			temp := h
 			fname := fmt.Sprintf("%s_%s", file, h.Address)
-			go worker(name, &temp, folder, fname, nmapArgs)
+			go worker(name, &temp, folder, fname, nmapArgs, native, origin)
 		}
 	}
 }
@@ -84,13 +100,29 @@ func execScan(name, target, folder, file, nmapArgs string) {
 // ---------------------------------------------------------------------------------------
 // WORKER
 // ---------------------------------------------------------------------------------------
-func worker(name string, h *model.Host, folder string, file string, nmapArgs string) {
+func worker(name string, h *model.Host, folder string, file string, nmapArgs string, native *nativeProfile, origin string) {
 	// Instantiate new NmapScan
 	s := NewScan(name, h.Address, folder, file, nmapArgs)
+	s.Origin = origin
 	ScansList = append(ScansList, s)
 
-	// Run the scan
-	s.RunNmap()
+	// The pipeline runs and stores its two phases itself
+	if name == pipelineScan {
+		s.RunPipeline(h, native)
+		return
+	}
+
+	// Run the scan, with nmap or the native engine
+	if native != nil {
+		s.RunNative(native)
+	} else {
+		s.RunNmap()
+	}
+
+	// Nothing to parse if the scan has been cancelled
+	if s.Status == model.CANCELLED {
+		return
+	}
 
 	// Parse nmap's output
 	res := s.ParseOutput()
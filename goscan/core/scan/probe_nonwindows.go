@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package scan
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Whether a connection attempt was answered with a RST
+func connRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package scan
+
+import (
+	"errors"
+	"syscall"
+)
+
+// WSAECONNREFUSED, not mapped to syscall.ECONNREFUSED on Windows
+const wsaeconnrefused = syscall.Errno(10061)
+
+// Whether a connection attempt was answered with a RST
+func connRefused(err error) bool {
+	return errors.Is(err, wsaeconnrefused) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,138 @@
+package scan
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+// ---------------------------------------------------------------------------------------
+// RULES OF ENGAGEMENT
+// ---------------------------------------------------------------------------------------
+var windowPollDelay = 5 * time.Second
+
+var (
+	windowLock   sync.Mutex
+	windowPaused bool
+	windowReason string
+)
+
+// Evaluate the scanning windows of the current workspace at the given time.
+// Returns whether scanning is allowed, and otherwise why not and what to do with running jobs
+func CheckWindow(now time.Time) (bool, string, string) {
+	if !utils.IsDBAvailable() {
+		return true, "", ""
+	}
+	windows := model.GetAllWindows(utils.Config.DB)
+
+	// Blackout periods take precedence over everything else
+	for _, w := range windows {
+		if w.Kind == model.WINDOW_BLACKOUT && w.Contains(now) {
+			reason := fmt.Sprintf("blackout period %s-%s UTC, ends at %s", w.Start, w.End, formatBoundary(w.NextBoundary(now, false)))
+			return false, reason, w.Action
+		}
+	}
+
+	// If allowed windows have been defined, we must be within one of them
+	var next time.Time
+	allowed, action := 0, model.WINDOW_ACTION_PAUSE
+	for _, w := range windows {
+		if w.Kind != model.WINDOW_ALLOW {
+			continue
+		}
+		if w.Contains(now) {
+			return true, "", ""
+		}
+		allowed++
+		// The strictest action wins
+		if w.Action == model.WINDOW_ACTION_CANCEL {
+			action = model.WINDOW_ACTION_CANCEL
+		}
+		if opens := w.NextBoundary(now, true); opens.After(now) && (next.IsZero() || opens.Before(next)) {
+			next = opens
+		}
+	}
+	if allowed == 0 {
+		return true, "", ""
+	}
+	if next.IsZero() {
+		return false, "outside of the allowed windows, none will open again", action
+	}
+	return false, fmt.Sprintf("outside of the allowed windows, next opens at %s", formatBoundary(next)), action
+}
+
+func formatBoundary(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+// Block until the scanning window is open, marking the job as waiting in the meantime
+func WaitForWindow(status *int, reason *string) {
+	for {
+		open, why, _ := CheckWindow(time.Now())
+		if open {
+			if *status == model.WAITING {
+				*status = model.IN_PROGRESS
+				*reason = ""
+			}
+			return
+		}
+		if *status != model.WAITING {
+			utils.Config.Log.LogInfo(fmt.Sprintf("Job queued: %s", why))
+		}
+		*status = model.WAITING
+		*reason = why
+		time.Sleep(windowPollDelay)
+	}
+}
+
+// Returns true (and the reason) if running jobs have been paused because the window closed
+func WindowPaused() (bool, string) {
+	windowLock.Lock()
+	defer windowLock.Unlock()
+	return windowPaused, windowReason
+}
+
+// Pause or cancel running jobs when the window closes, and resume them when it opens again
+func EnforceWindows() {
+	ticker := time.Tick(windowPollDelay)
+	wasOpen := true
+	for {
+		<-ticker
+
+		open, why, action := CheckWindow(time.Now())
+		switch {
+		case wasOpen && !open:
+			utils.Config.Log.LogWarning(fmt.Sprintf("Scanning window closed: %s", why))
+			if action == model.WINDOW_ACTION_PAUSE {
+				count, err := utils.PauseProcesses()
+				if err != nil {
+					utils.Config.Log.LogWarning(fmt.Sprintf("Cannot pause running jobs (%s), cancelling them instead", err))
+					action = model.WINDOW_ACTION_CANCEL
+				} else {
+					utils.Config.Log.LogWarning(fmt.Sprintf("Paused %d running command(s)", count))
+				}
+			}
+			if action == model.WINDOW_ACTION_CANCEL {
+				count := utils.CancelProcesses()
+				utils.Config.Log.LogWarning(fmt.Sprintf("Cancelled %d running command(s)", count))
+			}
+			windowLock.Lock()
+			windowPaused, windowReason = action == model.WINDOW_ACTION_PAUSE, why
+			windowLock.Unlock()
+
+		case !wasOpen && open:
+			windowLock.Lock()
+			if windowPaused {
+				count := utils.ResumeProcesses()
+				utils.Config.Log.LogNotify(fmt.Sprintf("Resumed %d paused command(s)", count))
+			}
+			windowPaused, windowReason = false, ""
+			windowLock.Unlock()
+			utils.Config.Log.LogNotify("Scanning window open")
+		}
+		wasOpen = open
+	}
+}
@@ -0,0 +1,73 @@
+package scan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marco-lancini/goscan/core/model"
+	"github.com/marco-lancini/goscan/core/utils"
+)
+
+func TestCheckWindow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goscan-schedule-test-")
+	if err != nil {
+		t.Fatalf("cannot create the workspace: %s", err)
+	}
+	utils.Config.DB = model.InitDB(filepath.Join(dir, "goscan.db"))
+	t.Cleanup(func() {
+		utils.Config.DB.Close()
+		utils.Config.DB = nil
+		os.RemoveAll(dir)
+	})
+
+	type window struct{ kind, start, end, action string }
+	office := window{model.WINDOW_ALLOW, "08:00", "18:00", model.WINDOW_ACTION_PAUSE}
+	night := window{model.WINDOW_ALLOW, "22:00", "06:00", model.WINDOW_ACTION_PAUSE}
+	lunch := window{model.WINDOW_BLACKOUT, "12:00", "13:00", model.WINDOW_ACTION_CANCEL}
+	christmas := window{model.WINDOW_BLACKOUT, "2026-12-24T18:00", "2026-12-26T08:00", model.WINDOW_ACTION_PAUSE}
+	pilot := window{model.WINDOW_ALLOW, "2026-03-02T09:00", "2026-03-02T11:00", model.WINDOW_ACTION_CANCEL}
+
+	cases := []struct {
+		name    string
+		windows []window
+		now     string
+		open    bool
+		action  string
+		reason  string
+	}{
+		{"no windows", nil, "2026-03-02T03:00", true, "", ""},
+		{"within a daily window", []window{office}, "2026-03-02T10:00", true, "", ""},
+		{"after a daily window", []window{office}, "2026-03-02T18:00", false, model.WINDOW_ACTION_PAUSE, "next opens at 2026-03-03 08:00 UTC"},
+		{"before a daily window", []window{office}, "2026-03-02T07:30", false, model.WINDOW_ACTION_PAUSE, "next opens at 2026-03-02 08:00 UTC"},
+		{"past midnight", []window{night}, "2026-03-03T02:00", true, "", ""},
+		{"outside a window past midnight", []window{night}, "2026-03-03T06:00", false, model.WINDOW_ACTION_PAUSE, "next opens at 2026-03-03 22:00 UTC"},
+		{"any allowed window", []window{office, night}, "2026-03-02T23:00", true, "", ""},
+		{"earliest allowed window", []window{office, night}, "2026-03-02T19:00", false, model.WINDOW_ACTION_PAUSE, "next opens at 2026-03-02 22:00 UTC"},
+		{"blackout within an allowed window", []window{office, lunch}, "2026-03-02T12:30", false, model.WINDOW_ACTION_CANCEL, "blackout period 12:00-13:00 UTC, ends at 2026-03-02 13:00 UTC"},
+		{"after a blackout", []window{office, lunch}, "2026-03-02T13:00", true, "", ""},
+		{"blackout without allowed windows", []window{lunch}, "2026-03-02T12:00", false, model.WINDOW_ACTION_CANCEL, "blackout period"},
+		{"dated blackout over a daily window", []window{office, christmas}, "2026-12-25T10:00", false, model.WINDOW_ACTION_PAUSE, "ends at 2026-12-26 08:00 UTC"},
+		{"after a dated blackout", []window{office, christmas}, "2026-12-26T08:00", true, "", ""},
+		{"within a dated window", []window{pilot}, "2026-03-02T09:00", true, "", ""},
+		{"before a dated window", []window{pilot}, "2026-03-01T09:00", false, model.WINDOW_ACTION_CANCEL, "next opens at 2026-03-02 09:00 UTC"},
+		{"after a dated window", []window{pilot}, "2026-03-02T11:00", false, model.WINDOW_ACTION_CANCEL, "none will open again"},
+		{"strictest action", []window{office, pilot}, "2026-03-02T20:00", false, model.WINDOW_ACTION_CANCEL, "next opens at 2026-03-03 08:00 UTC"},
+	}
+	for _, c := range cases {
+		model.DeleteAllWindows(utils.Config.DB)
+		for _, w := range c.windows {
+			if _, err := model.AddWindow(utils.Config.DB, w.kind, w.start, w.end, w.action); err != nil {
+				t.Fatalf("%s: cannot add window %s-%s: %s", c.name, w.start, w.end, err)
+			}
+		}
+		now, _ := time.Parse("2006-01-02T15:04", c.now)
+		open, reason, action := CheckWindow(now)
+		if open != c.open || action != c.action || !strings.Contains(reason, c.reason) || (c.open && reason != "") {
+			t.Errorf("%s: got %v %q %q, want %v %q (reason containing %q)", c.name, open, action, reason, c.open, c.action, c.reason)
+		}
+	}
+}
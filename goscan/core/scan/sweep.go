@@ -3,6 +3,7 @@ package scan
 import (
 	"fmt"
 
+	go_nmap "github.com/lair-framework/go-nmap"
 	"github.com/marco-lancini/goscan/core/model"
 	"github.com/marco-lancini/goscan/core/utils"
 )
@@ -14,9 +15,19 @@ func ScanSweep(kind string, target string) {
 	// Dispatch scan
 	switch kind {
 	case "PING":
-		utils.Config.Log.LogInfo("Starting Ping Sweep")
 		folder, file, nmapArgs := "sweep", "ping", utils.Const_NMAP_SWEEP
-		execSweep(file, target, folder, file, nmapArgs)
+		native := !utils.IsCommandAvailable("nmap")
+		if native {
+			utils.Config.Log.LogWarning("Nmap is not installed or not in PATH: falling back to the native ping sweep")
+		} else {
+			utils.Config.Log.LogInfo("Starting Ping Sweep")
+		}
+		execSweep(file, target, folder, file, nmapArgs, native)
+
+	case "NATIVE":
+		utils.Config.Log.LogInfo("Starting Native Ping Sweep (ICMP echo, TCP connect, ARP)")
+		folder, file := "sweep", "native"
+		execSweep(file, target, folder, file, "", true)
 
 	default:
 		utils.Config.Log.LogError("Invalid type of scan")
@@ -24,12 +35,15 @@ func ScanSweep(kind string, target string) {
 	}
 }
 
-func execSweep(name, target, folder, file, nmapArgs string) {
+func execSweep(name, target, folder, file, nmapArgs string, native bool) {
+	// Jobs are started asynchronously, keep track of the command that originated them
+	origin := utils.CurrentOrigin()
+
 	// If no database is available (Windows build), run directly against the provided target
 	if !utils.IsDBAvailable() {
 		temp := model.Target{Address: target, Step: model.IMPORTED.String()}
 		fname := fmt.Sprintf("%s_%s", file, target)
-		go workerSweep(name, &temp, folder, fname, nmapArgs)
+		go workerSweep(name, &temp, folder, fname, nmapArgs, origin, native)
 		return
 	}
 
@@ -44,7 +58,7 @@ func execSweep(name, target, folder, file, nmapArgs string) {
 			target == h.Address {
 			temp := h
 			fname := fmt.Sprintf("%s_%s", file, h.Address)
-			go workerSweep(name, &temp, folder, fname, nmapArgs)
+			go workerSweep(name, &temp, folder, fname, nmapArgs, origin, native)
 		}
 	}
 }
@@ -52,16 +66,29 @@ func execSweep(name, target, folder, file, nmapArgs string) {
 // ---------------------------------------------------------------------------------------
 // WORKER
 // ---------------------------------------------------------------------------------------
-func workerSweep(name string, h *model.Target, folder string, file string, nmapArgs string) {
+func workerSweep(name string, h *model.Target, folder string, file string, nmapArgs string, origin string, native bool) {
 	// Instantiate new NmapScan
 	s := NewScan(name, h.Address, folder, file, nmapArgs)
+	s.Origin = origin
 	ScansList = append(ScansList, s)
 
-	// Run the scan
-	s.RunNmap()
+	// Run the scan (the native sweep stores the live hosts itself)
+	if native {
+		s.RunPingSweep()
+	} else {
+		s.RunNmap()
+	}
+
+	// Nothing to parse if the scan has been cancelled
+	if s.Status == model.CANCELLED {
+		return
+	}
 
 	// Parse nmap's output
-	res := s.ParseOutput()
+	var res *go_nmap.NmapRun
+	if !native {
+		res = s.ParseOutput()
+	}
 	if res != nil {
 		// Identify live hosts
 		for _, host := range res.Hosts {
@@ -7,6 +7,25 @@ import (
 	"github.com/fatih/color"
 )
 
+// Disabled with --no-animation, or automatically when stdout is not a terminal
+var animationsEnabled = true
+
+// Enable or disable animations (and the artificial delays they introduce)
+func SetAnimations(enabled bool) {
+	animationsEnabled = enabled
+}
+
+func AnimationsEnabled() bool {
+	return animationsEnabled
+}
+
+// AnimationDelay sleeps for the given duration, only if animations are enabled
+func AnimationDelay(d time.Duration) {
+	if animationsEnabled {
+		time.Sleep(d)
+	}
+}
+
 // Animation effects and utilities
 var (
 	colorGreen   = color.New(color.FgGreen).SprintFunc()
@@ -20,6 +39,10 @@ var (
 
 // LoadingSpinner shows an animated loading spinner
 func LoadingSpinner(message string, duration time.Duration) {
+	if !animationsEnabled {
+		fmt.Printf("%s %s\n", colorGreen("✓"), message)
+		return
+	}
 	spinnerFrames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	endTime := time.Now().Add(duration)
 
@@ -37,6 +60,10 @@ func LoadingSpinner(message string, duration time.Duration) {
 
 // TypewriterEffect prints text with a typewriter effect
 func TypewriterEffect(text string, delay time.Duration) {
+	if !animationsEnabled {
+		fmt.Println(text)
+		return
+	}
 	for _, char := range text {
 		fmt.Print(string(char))
 		time.Sleep(delay)
@@ -78,6 +105,12 @@ func ScanStartAnimation(scanType string, target string) {
 	fmt.Printf(colorCyan("║ "))
 	TypewriterEffect("Starting "+scanType+" on "+target, 15*time.Millisecond)
 
+	if !animationsEnabled {
+		fmt.Println(colorCyan("║ ✓ Scan initializing..."))
+		fmt.Println(colorCyan("╚════════════════════════════════════════════════════════════════╝"))
+		fmt.Println()
+		return
+	}
 	frames := []string{"⠏", "⠛", "⠖", "⠒", "⠐", "⠠", "⠄"}
 	for i := 0; i < 3; i++ {
 		for _, frame := range frames {
@@ -103,10 +136,12 @@ func ScanCompleteAnimation(scanType string, target string, resultsCount int) {
 
 	// Confetti-like animation
 	confetti := []string{"✨", "🎉", "⭐", "💫", "🌟"}
-	for i := 0; i < 3; i++ {
-		fmt.Printf("%s ", colorYellow(confetti[i%len(confetti)]))
+	if animationsEnabled {
+		for i := 0; i < 3; i++ {
+			fmt.Printf("%s ", colorYellow(confetti[i%len(confetti)]))
+		}
+		fmt.Println()
 	}
-	fmt.Println()
 
 	// Scan summary
 	fmt.Printf("%s Scan Type: %s\n", colorGreen("►"), scanType)
@@ -114,10 +149,24 @@ func ScanCompleteAnimation(scanType string, target string, resultsCount int) {
 	fmt.Printf("%s Results Found: %s\n", colorGreen("►"), colorGreen(fmt.Sprintf("%d", resultsCount)))
 
 	fmt.Println()
-	for i := 0; i < 3; i++ {
-		fmt.Printf("%s ", colorYellow(confetti[i%len(confetti)]))
+	if animationsEnabled {
+		for i := 0; i < 3; i++ {
+			fmt.Printf("%s ", colorYellow(confetti[i%len(confetti)]))
+		}
+		fmt.Println()
+		fmt.Println()
 	}
+}
+
+// WarningBanner displays a warning that must not be missed (also when animations are disabled)
+func WarningBanner(title string, lines []string) {
 	fmt.Println()
+	fmt.Println(color.New(color.FgYellow, color.Bold).Sprint("╔════════════════════════════════════════════════════════════════╗"))
+	fmt.Println(color.New(color.FgYellow, color.Bold).Sprintf("║ ⚠ %-60s ║", title))
+	fmt.Println(color.New(color.FgYellow, color.Bold).Sprint("╚════════════════════════════════════════════════════════════════╝"))
+	for _, line := range lines {
+		fmt.Printf("%s %s\n", color.New(color.FgYellow).Sprint("►"), line)
+	}
 	fmt.Println()
 }
 
@@ -137,6 +186,9 @@ func ScanFailedAnimation(scanType string, target string, errorMsg string) {
 
 // MenuTransition shows smooth transition between menus
 func MenuTransition(fromTitle string, toTitle string) {
+	if !animationsEnabled {
+		return
+	}
 	fmt.Println()
 	for i := 0; i < 3; i++ {
 		fmt.Printf("\r%s", colorCyan("|"))
@@ -154,6 +206,10 @@ func MenuTransition(fromTitle string, toTitle string) {
 
 // PulseEffect creates a pulsing animation effect
 func PulseEffect(message string, pulses int) {
+	if !animationsEnabled {
+		fmt.Println(colorMagenta(message))
+		return
+	}
 	for i := 0; i < pulses; i++ {
 		fmt.Printf("\r%s", colorMagenta(message))
 		time.Sleep(200 * time.Millisecond)
@@ -165,6 +221,9 @@ func PulseEffect(message string, pulses int) {
 
 // WaveEffect shows a wave animation
 func WaveEffect() {
+	if !animationsEnabled {
+		return
+	}
 	waves := []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█", "▇", "▆", "▅", "▄", "▃", "▂"}
 	for i := 0; i < 3; i++ {
 		for _, wave := range waves {
@@ -181,13 +240,17 @@ func FadeInText(text string, lines []string) {
 	fmt.Println(colorCyan(text))
 	for _, line := range lines {
 		fmt.Println(colorYellow("  " + line))
-		time.Sleep(100 * time.Millisecond)
+		AnimationDelay(100 * time.Millisecond)
 	}
 	fmt.Println()
 }
 
 // ScrollText displays text that scrolls in from left to right
 func ScrollText(text string) {
+	if !animationsEnabled {
+		fmt.Println(colorCyan(text))
+		return
+	}
 	padding := ""
 	for i := 0; i < len(text); i++ {
 		padding += text[i : i+1]
@@ -205,7 +268,7 @@ func AnimatedBox(title string, content []string) {
 	fmt.Print(colorCyan("╔"))
 	for i := 0; i < boxWidth-2; i++ {
 		fmt.Print(colorCyan("═"))
-		time.Sleep(5 * time.Millisecond)
+		AnimationDelay(5 * time.Millisecond)
 	}
 	fmt.Println(colorCyan("╗"))
 
@@ -230,14 +293,14 @@ func AnimatedBox(title string, content []string) {
 		fmt.Print(colorCyan("║ "))
 		fmt.Print(line)
 		fmt.Println(colorCyan(" ║"))
-		time.Sleep(100 * time.Millisecond)
+		AnimationDelay(100 * time.Millisecond)
 	}
 
 	// Bottom border
 	fmt.Print(colorCyan("╚"))
 	for i := 0; i < boxWidth-2; i++ {
 		fmt.Print(colorCyan("═"))
-		time.Sleep(5 * time.Millisecond)
+		AnimationDelay(5 * time.Millisecond)
 	}
 	fmt.Println(colorCyan("╝"))
 	fmt.Println()
@@ -245,6 +308,10 @@ func AnimatedBox(title string, content []string) {
 
 // CountdownTimer shows an animated countdown
 func CountdownTimer(seconds int) {
+	if !animationsEnabled {
+		fmt.Println(colorGreen("✓ Ready!"))
+		return
+	}
 	for i := seconds; i > 0; i-- {
 		fmt.Printf("\r%s %d seconds...", colorYellow("⏱"), i)
 		time.Sleep(1 * time.Second)
@@ -254,6 +321,10 @@ func CountdownTimer(seconds int) {
 
 // BlinkText makes text blink
 func BlinkText(text string, times int) {
+	if !animationsEnabled {
+		fmt.Println(colorMagenta(text))
+		return
+	}
 	for i := 0; i < times*2; i++ {
 		if i%2 == 0 {
 			fmt.Printf("\r%s", colorMagenta(text))
@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------------------
+// CONSTANTS
+// ---------------------------------------------------------------------------------------
+const (
+	AUDIT_ACTION  = "ACTION"  // goscan command issued by the operator
+	AUDIT_COMMAND = "COMMAND" // external command run on behalf of a goscan command
+	AUDIT_PROBE   = "PROBE"   // network probe sent natively (no external command)
+)
+
+var Const_AUDIT_FILE = "audit.log"
+
+// ---------------------------------------------------------------------------------------
+// JOB CONTEXT
+// ---------------------------------------------------------------------------------------
+// Context of the job an external command is run for
+type Job struct {
+	ID     int
+	Kind   string
+	Target string
+	Origin string
+}
+
+var (
+	originLock    sync.Mutex
+	currentOrigin string
+)
+
+// Returns the goscan command currently being executed
+func CurrentOrigin() string {
+	originLock.Lock()
+	defer originLock.Unlock()
+	return currentOrigin
+}
+
+// ---------------------------------------------------------------------------------------
+// AUDIT LOG
+// ---------------------------------------------------------------------------------------
+// Each entry embeds the hash of the previous one, so that the log is tamper-evident
+type AuditEntry struct {
+	Seq        int       `json:"seq"`
+	Kind       string    `json:"kind"`
+	Operator   string    `json:"operator"`
+	Origin     string    `json:"origin"`
+	JobID      int       `json:"job_id,omitempty"`
+	JobKind    string    `json:"job_kind,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	Argv       []string  `json:"argv,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	ExitStatus int       `json:"exit_status"`
+	Error      string    `json:"error,omitempty"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+type auditLog struct {
+	sync.Mutex
+	path     string
+	seq      int
+	lastHash string
+	operator string
+}
+
+var audit = &auditLog{}
+
+// Open (or create) the audit log of the current workspace, and restore its hash chain
+func InitAudit() {
+	audit.Lock()
+	defer audit.Unlock()
+
+	audit.path = filepath.Join(Config.Outfolder, Const_AUDIT_FILE)
+	audit.seq, audit.lastHash = 0, ""
+	audit.operator = auditOperator()
+
+	entries, err := ReadAudit(audit.path)
+	if err != nil {
+		Config.Log.LogError(fmt.Sprintf("Cannot read audit log: %s", err))
+		return
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		audit.seq, audit.lastHash = last.Seq, last.Hash
+	}
+}
+
+// The operator is taken from GOSCAN_OPERATOR, falling back to the (sudo) user running goscan
+func auditOperator() string {
+	if op := os.Getenv("GOSCAN_OPERATOR"); op != "" {
+		return op
+	}
+	if op := os.Getenv("SUDO_USER"); op != "" {
+		return op
+	}
+	if usr, err := user.Current(); err == nil {
+		return usr.Username
+	}
+	return "unknown"
+}
+
+// Record a goscan command issued by the operator, which becomes the origin of the jobs it starts
+func AuditAction(cmd string) {
+	originLock.Lock()
+	currentOrigin = cmd
+	originLock.Unlock()
+
+	now := time.Now().UTC()
+	appendAudit(AuditEntry{
+		Kind:   AUDIT_ACTION,
+		Origin: cmd,
+		Start:  now,
+		End:    now,
+	})
+}
+
+// Record a native probe (e.g., HTTP request) sent on behalf of a job
+func AuditProbe(job Job, argv []string, start, end time.Time, err error) {
+	auditEntry(AUDIT_PROBE, job, argv, start, end, 0, err)
+}
+
+// Record an external command run on behalf of a job
+func auditCommand(job Job, argv []string, start, end time.Time, exitStatus int, err error) {
+	auditEntry(AUDIT_COMMAND, job, argv, start, end, exitStatus, err)
+}
+
+func auditEntry(kind string, job Job, argv []string, start, end time.Time, exitStatus int, err error) {
+	e := AuditEntry{
+		Kind:       kind,
+		Origin:     job.Origin,
+		JobID:      job.ID,
+		JobKind:    job.Kind,
+		Target:     job.Target,
+		Argv:       argv,
+		Start:      start.UTC(),
+		End:        end.UTC(),
+		ExitStatus: exitStatus,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	appendAudit(e)
+}
+
+func appendAudit(e AuditEntry) {
+	audit.Lock()
+	defer audit.Unlock()
+	if audit.path == "" {
+		return
+	}
+
+	e.Seq = audit.seq + 1
+	e.Operator = audit.operator
+	e.PrevHash = audit.lastHash
+	e.Hash = e.computeHash()
+	line, err := json.Marshal(e)
+	if err != nil {
+		Config.Log.LogError(fmt.Sprintf("Cannot serialize audit entry: %s", err))
+		return
+	}
+
+	// Append-only: entries are never rewritten
+	f, err := os.OpenFile(audit.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		Config.Log.LogError(fmt.Sprintf("Cannot open audit log: %s", err))
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		Config.Log.LogError(fmt.Sprintf("Cannot write audit log: %s", err))
+		return
+	}
+	audit.seq, audit.lastHash = e.Seq, e.Hash
+}
+
+// Hash of the entry (excluding the hash itself), chained to the previous one
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(append([]byte(e.PrevHash), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Parse an audit log file, a missing file is an empty log
+func ReadAudit(path string) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e := AuditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("malformed entry after seq %d: %s", len(entries), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Check the hash chain, returns an error describing every broken link (modified entries,
+// and gaps left by removed ones)
+func VerifyAudit(entries []AuditEntry) error {
+	problems := []string{}
+	prev, seq := "", 0
+	for _, e := range entries {
+		if e.Seq != seq+1 {
+			problems = append(problems, fmt.Sprintf("entry %d follows entry %d, entries have been removed or reordered", e.Seq, seq))
+		} else if e.PrevHash != prev {
+			problems = append(problems, fmt.Sprintf("entry %d does not chain to the previous one", e.Seq))
+		}
+		if e.computeHash() != e.Hash {
+			problems = append(problems, fmt.Sprintf("entry %d has been modified", e.Seq))
+		}
+		prev, seq = e.Hash, e.Seq
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Verify the audit log of the current workspace and export it as a JSON document
+func ExportAudit(dst string) (int, error) {
+	audit.Lock()
+	path := audit.path
+	audit.Unlock()
+
+	entries, err := ReadAudit(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := VerifyAudit(entries); err != nil {
+		return 0, fmt.Errorf("audit log failed verification: %s", err)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), ioutil.WriteFile(dst, data, 0600)
+}
+
+// Path of the audit log of the current workspace
+func AuditPath() string {
+	audit.Lock()
+	defer audit.Unlock()
+	return audit.path
+}
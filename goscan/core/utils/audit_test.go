@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// Audit log of a temporary workspace
+func testAudit(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "goscan-audit-test-")
+	if err != nil {
+		t.Fatalf("cannot create the workspace: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	Config.Outfolder = dir
+	Config.Log = InitLogger()
+	InitAudit()
+	return AuditPath()
+}
+
+func TestAuditChain(t *testing.T) {
+	path := testAudit(t)
+	job := Job{ID: 1, Kind: "TCP-STANDARD", Target: "10.0.0.1", Origin: "portscan TCP-STANDARD 10.0.0.1"}
+	AuditAction("portscan TCP-STANDARD 10.0.0.1")
+	AuditProbe(job, []string{"GET", "http://10.0.0.1/"}, time.Now(), time.Now(), nil)
+	AuditProbe(job, []string{"GET", "http://10.0.0.1/admin"}, time.Now(), time.Now(), errors.New("connection refused"))
+
+	// The chain goes on after a restart
+	InitAudit()
+	AuditAction("show hosts")
+	AuditAction("show ports")
+
+	entries, err := ReadAudit(path)
+	if err != nil {
+		t.Fatalf("cannot read the audit log: %s", err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			t.Errorf("entry %d: got sequence number %d", i+1, e.Seq)
+		}
+	}
+	if entries[2].Kind != AUDIT_PROBE || entries[2].Error != "connection refused" || entries[2].JobID != 1 {
+		t.Errorf("probe entry: got %+v", entries[2])
+	}
+	if err := VerifyAudit(entries); err != nil {
+		t.Fatalf("untouched log: %s", err)
+	}
+}
+
+func TestAuditTampering(t *testing.T) {
+	path := testAudit(t)
+	for _, cmd := range []string{"load target SINGLE 10.0.0.1", "portscan TCP-FULL 10.0.0.1", "enumerate ALL POLITE ALL", "show creds", "creds export /tmp/creds.csv"} {
+		AuditAction(cmd)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read the audit log: %s", err)
+	}
+
+	// Rewrite the command of entry 2 (keeping its hash), and remove entry 4
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	e := AuditEntry{}
+	if err := json.Unmarshal(lines[1], &e); err != nil {
+		t.Fatalf("cannot parse entry 2: %s", err)
+	}
+	e.Origin = "portscan TCP-STANDARD 10.0.0.1"
+	if lines[1], err = json.Marshal(e); err != nil {
+		t.Fatalf("cannot serialize entry 2: %s", err)
+	}
+	lines = append(lines[:3], lines[4:]...)
+	if err := ioutil.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600); err != nil {
+		t.Fatalf("cannot write the audit log: %s", err)
+	}
+
+	entries, err := ReadAudit(path)
+	if err != nil {
+		t.Fatalf("cannot read the audit log: %s", err)
+	}
+	// Both are reported, and nothing else (entry 3 still chains to the stored hash of entry 2)
+	want := "entry 2 has been modified; entry 5 follows entry 3, entries have been removed or reordered"
+	if err := VerifyAudit(entries); err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
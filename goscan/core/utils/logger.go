@@ -1,42 +1,273 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/fatih/color"
 )
 
+// ---------------------------------------------------------------------------------------
+// CONSTANTS
+// ---------------------------------------------------------------------------------------
+type Level int
+
+const (
+	LEVEL_DEBUG Level = iota
+	LEVEL_INFO
+	LEVEL_NOTIFY
+	LEVEL_WARNING
+	LEVEL_ERROR
+)
+
+func (l Level) String() string {
+	return [...]string{"DEBUG", "INFO", "NOTIFY", "WARNING", "ERROR"}[l]
+}
+
+// Parse a level name (case insensitive)
+func ParseLevel(s string) (Level, error) {
+	for l := LEVEL_DEBUG; l <= LEVEL_ERROR; l++ {
+		if strings.EqualFold(s, l.String()) {
+			return l, nil
+		}
+	}
+	return LEVEL_INFO, fmt.Errorf("invalid log level: %s", s)
+}
+
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+)
+
+var Const_LOG_FOLDER = "logs"
+var Const_LOG_FILE = "goscan.log"
+var Const_LOG_MAX_SIZE int64 = 10 * 1024 * 1024
+var Const_LOG_MAX_BACKUPS = 5
+
 // ---------------------------------------------------------------------------------------
 // LOGGER
 // ---------------------------------------------------------------------------------------
-type Logger struct{}
+// Loggers derived with WithJob share the same sinks, but carry their own fields
+type Logger struct {
+	sink   *logSink
+	fields map[string]interface{}
+}
+
+// Console and (optional) rotating file sinks
+type logSink struct {
+	sync.Mutex
+	level  Level
+	format string
+	file   *rotatingFile
+}
+
+// Used when logging before the global config has been initialized
+var defaultLogger = &Logger{sink: &logSink{level: LEVEL_INFO, format: LOG_FORMAT_TEXT}}
 
 func InitLogger() *Logger {
-	return &Logger{}
+	return &Logger{sink: &logSink{level: LEVEL_INFO, format: LOG_FORMAT_TEXT}}
+}
+
+// Return a logger which adds the fields of the job to every record
+func (l *Logger) WithJob(job Job) *Logger {
+	if l == nil {
+		l = defaultLogger
+	}
+	fields := map[string]interface{}{}
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	if job.ID != 0 {
+		fields["job_id"] = job.ID
+	}
+	if job.Target != "" {
+		fields["target"] = job.Target
+	}
+	if job.Kind != "" {
+		fields["kind"] = job.Kind
+	}
+	return &Logger{sink: l.sink, fields: fields}
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.sink.Lock()
+	defer l.sink.Unlock()
+	l.sink.level = level
+}
+
+func (l *Logger) SetFormat(format string) error {
+	if format != LOG_FORMAT_TEXT && format != LOG_FORMAT_JSON {
+		return fmt.Errorf("invalid log format: %s", format)
+	}
+	l.sink.Lock()
+	defer l.sink.Unlock()
+	l.sink.format = format
+	return nil
+}
+
+// Write logs to a rotating file in the given (workspace) folder
+func (l *Logger) SetOutput(folder string) error {
+	dir := filepath.Join(folder, Const_LOG_FOLDER)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := openRotatingFile(filepath.Join(dir, Const_LOG_FILE), Const_LOG_MAX_SIZE, Const_LOG_MAX_BACKUPS)
+	if err != nil {
+		return err
+	}
+	l.sink.Lock()
+	defer l.sink.Unlock()
+	if l.sink.file != nil {
+		l.sink.file.Close()
+	}
+	l.sink.file = f
+	return nil
 }
 
 func (l *Logger) LogDebug(message string) {
-	highlight := color.New(color.FgWhite).SprintFunc()
-	reset := color.New(color.FgWhite).SprintFunc()
-	fmt.Println(highlight("[-]"), reset(message))
+	l.log(LEVEL_DEBUG, message)
 }
 
 func (l *Logger) LogInfo(message string) {
-	highlight := color.New(color.FgBlue).SprintFunc()
-	reset := color.New(color.FgWhite).SprintFunc()
-	fmt.Println(highlight("[*]"), reset(message))
+	l.log(LEVEL_INFO, message)
 }
 
 func (l *Logger) LogNotify(message string) {
-	highlight := color.New(color.FgGreen).SprintFunc()
-	fmt.Println(highlight("[+]"), highlight(message))
+	l.log(LEVEL_NOTIFY, message)
 }
 
 func (l *Logger) LogWarning(message string) {
-	highlight := color.New(color.FgYellow).SprintFunc()
-	fmt.Println(highlight("[?]"), highlight(message))
+	l.log(LEVEL_WARNING, message)
 }
 
 func (l *Logger) LogError(message string) {
-	highlight := color.New(color.FgRed).SprintFunc()
-	fmt.Println(highlight("[!]"), highlight(message))
+	l.log(LEVEL_ERROR, message)
+}
+
+func (l *Logger) log(level Level, message string) {
+	if l == nil || l.sink == nil {
+		l = defaultLogger
+	}
+	l.sink.Lock()
+	defer l.sink.Unlock()
+	if level < l.sink.level {
+		return
+	}
+
+	// Console
+	switch level {
+	case LEVEL_DEBUG:
+		highlight := color.New(color.FgWhite).SprintFunc()
+		fmt.Println(highlight("[-]"), highlight(message))
+	case LEVEL_INFO:
+		highlight := color.New(color.FgBlue).SprintFunc()
+		reset := color.New(color.FgWhite).SprintFunc()
+		fmt.Println(highlight("[*]"), reset(message))
+	case LEVEL_NOTIFY:
+		highlight := color.New(color.FgGreen).SprintFunc()
+		fmt.Println(highlight("[+]"), highlight(message))
+	case LEVEL_WARNING:
+		highlight := color.New(color.FgYellow).SprintFunc()
+		fmt.Println(highlight("[?]"), highlight(message))
+	case LEVEL_ERROR:
+		highlight := color.New(color.FgRed).SprintFunc()
+		fmt.Println(highlight("[!]"), highlight(message))
+	}
+
+	// File
+	if l.sink.file != nil {
+		l.sink.file.Write(l.format(level, message))
+	}
+}
+
+// Serialize a record for the file sink, either as plain text or as a JSON line
+func (l *Logger) format(level Level, message string) []byte {
+	now := time.Now().UTC()
+	if l.sink.format == LOG_FORMAT_JSON {
+		record := map[string]interface{}{}
+		for k, v := range l.fields {
+			record[k] = v
+		}
+		record["time"] = now.Format(time.RFC3339Nano)
+		record["level"] = level.String()
+		record["msg"] = message
+		data, _ := json.Marshal(record)
+		return append(data, '\n')
+	}
+
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := ""
+	for _, k := range keys {
+		fields = fmt.Sprintf("%s %s=%v", fields, k, l.fields[k])
+	}
+	if fields != "" {
+		fields = fmt.Sprintf(" [%s]", strings.TrimSpace(fields))
+	}
+	return []byte(fmt.Sprintf("%s %-7s%s %s\n", now.Format(time.RFC3339), level.String(), fields, message))
+}
+
+// ---------------------------------------------------------------------------------------
+// ROTATING FILE
+// ---------------------------------------------------------------------------------------
+// Size-based rotation: goscan.log -> goscan.log.1 -> ... -> goscan.log.<backups>
+type rotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+	size    int64
+	f       *os.File
+}
+
+func openRotatingFile(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, backups: backups}
+	return r, r.open()
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.size+int64(len(p)) > r.maxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	os.Rename(r.path, fmt.Sprintf("%s.1", r.path))
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	return r.f.Close()
 }
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------------------
+// PROCESS TRACKING
+// ---------------------------------------------------------------------------------------
+// Returned by ShellCmd when a command has been killed because the scanning window closed
+var ErrCancelled = errors.New("command cancelled: outside of the allowed scanning window")
+
+var (
+	procLock sync.Mutex
+	// Running external commands, mapped to whether they have been cancelled
+	procs = map[*exec.Cmd]bool{}
+)
+
+func trackProcess(c *exec.Cmd) {
+	procLock.Lock()
+	defer procLock.Unlock()
+	procs[c] = false
+}
+
+// Stop tracking a finished command, returns true if it had been cancelled
+func untrackProcess(c *exec.Cmd) bool {
+	procLock.Lock()
+	defer procLock.Unlock()
+	cancelled := procs[c]
+	delete(procs, c)
+	return cancelled
+}
+
+// Suspend all running external commands, returns how many have been paused
+func PauseProcesses() (int, error) {
+	procLock.Lock()
+	defer procLock.Unlock()
+	count := 0
+	for c := range procs {
+		if err := pauseProcess(c); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Resume all the external commands previously paused
+func ResumeProcesses() int {
+	procLock.Lock()
+	defer procLock.Unlock()
+	count := 0
+	for c := range procs {
+		if err := resumeProcess(c); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Kill all running external commands, returns how many have been cancelled
+func CancelProcesses() int {
+	procLock.Lock()
+	defer procLock.Unlock()
+	count := 0
+	for c := range procs {
+		if err := killProcess(c); err == nil {
+			procs[c] = true
+			count++
+		}
+	}
+	return count
+}
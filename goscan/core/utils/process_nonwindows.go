@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Run the command in its own process group, so that signals reach the whole pipeline
+func configureProcess(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func pauseProcess(c *exec.Cmd) error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGSTOP)
+}
+
+func resumeProcess(c *exec.Cmd) error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGCONT)
+}
+
+func killProcess(c *exec.Cmd) error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func configureProcess(c *exec.Cmd) {}
+
+// Windows has no equivalent of SIGSTOP, callers should fall back to cancelling
+func pauseProcess(c *exec.Cmd) error {
+	return errors.New("pausing processes is not supported on Windows")
+}
+
+func resumeProcess(c *exec.Cmd) error {
+	return nil
+}
+
+func killProcess(c *exec.Cmd) error {
+	return c.Process.Kill()
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,9 +9,12 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/jinzhu/gorm"
 	"github.com/marco-lancini/goscan/core/model"
+	"github.com/mattn/go-isatty"
 )
 
 // ---------------------------------------------------------------------------------------
@@ -32,6 +36,20 @@ var Const_NMAP_TCP_VULN = "--randomize-hosts -Pn -sT -sV -p- --script=vulscan/vu
 var Const_NMAP_UDP_STANDARD = fmt.Sprintf("--randomize-hosts -Pn -sU -sC -A -T4 -p%s", Const_UDP_PORTS)
 var Const_NMAP_UDP_PROD = fmt.Sprintf("--randomize-hosts -Pn -sU -sC -sV -T3 -p%s", Const_UDP_PORTS)
 
+// TCP pipeline: fast discovery of the open ports, then version detection and default
+// scripts on the open ports only (the port list is appended per host)
+var Const_NMAP_TCP_DISCOVERY = "--randomize-hosts -Pn -sS -T4 -g53 --min-rate 1000 --max-retries 1 -p-"
+var Const_NMAP_TCP_SERVICES = "-Pn -sV -sC -T4 -g53"
+
+// ICS: Modbus, S7, DNP3, EtherNet/IP, BACnet - connect scan only, one probe at a time, no version detection
+var Const_ICS_PORTS = "T:102,502,20000,44818,U:47808"
+var Const_NMAP_ICS = fmt.Sprintf("--randomize-hosts -Pn -sT -sU -T2 --max-retries 1 --max-parallelism 1 --scan-delay 1s -p%s", Const_ICS_PORTS)
+var Const_ICS_WARNING = []string{
+	"Industrial devices (PLCs, RTUs, HMIs) can crash or misbehave when probed.",
+	"Only read-only identification probes are sent, at a slow rate: AGGRESSIVE is rejected.",
+	"Make sure the scan is authorised by the asset owner and scheduled in a maintenance window.",
+}
+
 // WORDLISTS
 var WORDLIST_FUZZ_NAMELIST = "/usr/share/wfuzz/wordlist/fuzzdb/wordlists-user-passwd/names/namelist.txt"
 var WORDLIST_MSF_PWDS = "/usr/share/wordlists/metasploit/unix_passwords.txt"
@@ -44,15 +62,97 @@ var WORDLIST_HYDRA_SSH_USER = WORDLIST_FUZZ_NAMELIST
 var WORDLIST_HYDRA_SSH_PWD = WORDLIST_MSF_PWDS
 var WORDLIST_HYDRA_FTP_USER = WORDLIST_FUZZ_NAMELIST
 var WORDLIST_HYDRA_FTP_PWD = WORDLIST_MSF_PWDS
+var WORDLIST_KERBEROS_USER = WORDLIST_FUZZ_NAMELIST
+
+// Wordlists by the name used in "set wordlists"
+var wordlists = map[string]*string{
+	"FINGER_USER":        &WORDLIST_FINGER_USER,
+	"FTP_USER":           &WORDLIST_FTP_USER,
+	"SMTP":               &WORDLIST_SMTP,
+	"SNMP":               &WORDLIST_SNMP,
+	"DNS_BRUTEFORCE":     &WORDLIST_DNS_BRUTEFORCE,
+	"HYDRA_SSH_USER":     &WORDLIST_HYDRA_SSH_USER,
+	"HYDRA_SSH_PASSWORD": &WORDLIST_HYDRA_SSH_PWD,
+	"HYDRA_FTP_USER":     &WORDLIST_HYDRA_FTP_USER,
+	"HYDRA_FTP_PASSWORD": &WORDLIST_HYDRA_FTP_PWD,
+	"KERBEROS_USER":      &WORDLIST_KERBEROS_USER,
+}
+
+// SNMPv3 credentials of the native SNMP client ("set snmp_v3"), kept in memory only.
+// Protocols: MD5/SHA/SHA256/SHA512 for authentication, DES/AES/AES256 for privacy
+type SNMPv3Credentials struct {
+	User           string
+	AuthProtocol   string
+	AuthPassphrase string
+	PrivProtocol   string
+	PrivPassphrase string
+}
+
+var SNMP_V3 = SNMPv3Credentials{}
+
+// SCAN ENGINE
+// Port scans run with nmap, or with the built-in TCP connect scanner ("set engine"),
+// which is also used when nmap is not installed
+const (
+	ENGINE_NMAP   = "nmap"
+	ENGINE_NATIVE = "native"
+)
+
+var ENGINE = ENGINE_NMAP
+
+// Options of the built-in TCP connect scanner ("set native_scan"): ports overrides the
+// ports of the scan profile (e.g., "22,80,8000-8100" or "top-100"), rate is in probes/s,
+// banners enables the identification of the services of the open ports
+type NativeScanOptions struct {
+	Ports      string
+	Timeout    time.Duration
+	Retries    int
+	Rate       int
+	Workers    int
+	Banners    bool
+	SweepPorts string // TCP probes of the native ping sweep
+}
+
+// Highest rate of the native scanner (probes/s)
+const NATIVE_MAX_RATE = 100000
+
+var NATIVE_SCAN = NativeScanOptions{
+	Timeout:    1500 * time.Millisecond,
+	Retries:    1,
+	Rate:       500,
+	Workers:    100,
+	Banners:    true,
+	SweepPorts: "22,80,135,139,443,445,3389",
+}
+
+// Returns the current path of a wordlist, given its name
+func Wordlist(name string) (string, error) {
+	w, ok := wordlists[name]
+	if !ok {
+		return "", fmt.Errorf("unknown wordlist: %s", name)
+	}
+	return *w, nil
+}
 
 // ---------------------------------------------------------------------------------------
 // CONFIG
 // ---------------------------------------------------------------------------------------
+// Command line options, parsed before the global config is initialized
+var Options options
+
+type options struct {
+	LogLevel    string
+	LogFormat   string
+	NoAnimation bool
+	NoColor     bool
+}
+
 type config struct {
-	Outfolder string
-	Log       *Logger
-	DB        *gorm.DB
-	DBPath    string
+	Outfolder     string
+	RecipesFolder string
+	Log           *Logger
+	DB            *gorm.DB
+	DBPath        string
 }
 
 // Initialize global config (db, logger, etc.)
@@ -62,6 +162,8 @@ func InitConfig() {
 
 	// Initialize logger
 	Config.Log = InitLogger()
+	configureLogger()
+	configureTerminal()
 
 	// Create output folder
 	if os.Getenv("OUT_FOLDER") != "" {
@@ -71,6 +173,18 @@ func InitConfig() {
 		Config.Outfolder = filepath.Join(usr.HomeDir, ".goscan")
 	}
 	EnsureDir(Config.Outfolder)
+	InitAudit()
+	if err := Config.Log.SetOutput(Config.Outfolder); err != nil {
+		Config.Log.LogError(fmt.Sprintf("Cannot write log file: %s", err))
+	}
+	InitVault()
+
+	// User-defined enumeration recipes
+	if os.Getenv("GOSCAN_RECIPES") != "" {
+		Config.RecipesFolder = os.Getenv("GOSCAN_RECIPES")
+	} else {
+		Config.RecipesFolder = filepath.Join(Config.Outfolder, "recipes")
+	}
 
 	// Init DB (skip on Windows due to CGO requirements)
 	if os.Getenv("GOSCAN_DB_PATH") != "" {
@@ -89,11 +203,59 @@ func InitConfig() {
 	}
 }
 
+// Log level and format: command line options take precedence over the environment
+// (GOSCAN_LOG_LEVEL, GOSCAN_LOG_FORMAT), DEBUG=1 is kept as a shortcut for the debug level
+func configureLogger() {
+	level := Options.LogLevel
+	if level == "" {
+		level = os.Getenv("GOSCAN_LOG_LEVEL")
+	}
+	if level == "" && os.Getenv("DEBUG") == "1" {
+		level = LEVEL_DEBUG.String()
+	}
+	if level != "" {
+		parsed, err := ParseLevel(level)
+		if err != nil {
+			Config.Log.LogError(err.Error())
+		}
+		Config.Log.SetLevel(parsed)
+	}
+
+	format := Options.LogFormat
+	if format == "" {
+		format = os.Getenv("GOSCAN_LOG_FORMAT")
+	}
+	if format != "" {
+		if err := Config.Log.SetFormat(format); err != nil {
+			Config.Log.LogError(err.Error())
+		}
+	}
+}
+
+// Disable animations and colours if requested, or if stdout is not a terminal (scripts, pipes)
+func configureTerminal() {
+	tty := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	if Options.NoColor || !tty {
+		color.NoColor = true
+	}
+	if Options.NoAnimation || !tty {
+		SetAnimations(false)
+	}
+}
+
 // Change output folder as instructed by the user and re-init the db
 func ChangeOutFolder(path string) {
 	// Create the folder
 	Config.Outfolder = path
 	EnsureDir(Config.Outfolder)
+	InitAudit()
+	if err := Config.Log.SetOutput(Config.Outfolder); err != nil {
+		Config.Log.LogError(fmt.Sprintf("Cannot write log file: %s", err))
+	}
+	InitVault()
+	if os.Getenv("GOSCAN_RECIPES") == "" {
+		Config.RecipesFolder = filepath.Join(Config.Outfolder, "recipes")
+	}
 
 	// Reinit the DB
 	Config.DBPath = filepath.Join(Config.Outfolder, "goscan.db")
@@ -132,7 +294,16 @@ func ParseAllArgs(args []string) string {
 }
 
 func ShellCmd(cmd string) (string, error) {
-	Config.Log.LogDebug(fmt.Sprintf("Executing command: %s", cmd))
+	return ShellCmdJob(Job{}, cmd)
+}
+
+// Execute a command on behalf of a job, recording it in the audit log
+func ShellCmdJob(job Job, cmd string) (string, error) {
+	log := Config.Log.WithJob(job)
+	log.LogDebug(fmt.Sprintf("Executing command: %s", cmd))
+	if job.Origin == "" {
+		job.Origin = CurrentOrigin()
+	}
 
 	var execCmd *exec.Cmd
 	if runtime.GOOS == "windows" {
@@ -142,15 +313,39 @@ func ShellCmd(cmd string) (string, error) {
 		execCmd = exec.Command("sh", "-c", cmd)
 	}
 
-	output, err := execCmd.CombinedOutput()
+	// Track the process, so that it can be paused/cancelled according to the scanning windows
+	var buf bytes.Buffer
+	execCmd.Stdout = &buf
+	execCmd.Stderr = &buf
+	configureProcess(execCmd)
+	start := time.Now()
+	err := execCmd.Start()
+	if err == nil {
+		trackProcess(execCmd)
+		err = execCmd.Wait()
+		if untrackProcess(execCmd) {
+			err = ErrCancelled
+		}
+	}
+	exitStatus := -1
+	if execCmd.ProcessState != nil {
+		exitStatus = execCmd.ProcessState.ExitCode()
+	}
+	auditCommand(job, execCmd.Args, start, time.Now(), exitStatus, err)
+
+	output := buf.Bytes()
+	if err == ErrCancelled {
+		log.LogWarning(fmt.Sprintf("Command cancelled: %s", cmd))
+		return string(output), err
+	}
 	if err != nil {
 		// Provide clearer context when a dependency/command is missing
 		lowered := strings.ToLower(err.Error())
 		if strings.Contains(lowered, "not recognized") || strings.Contains(lowered, "executable file not found") || strings.Contains(lowered, "no such file or directory") {
-			Config.Log.LogError("Dependency missing or command not in PATH. Please ensure the required tool is installed.")
+			log.LogError("Dependency missing or command not in PATH. Please ensure the required tool is installed.")
 		}
 		// Always log command failure with captured output
-		Config.Log.LogError(fmt.Sprintf("Command failed: %s\nOutput: %s\nError: %v", cmd, strings.TrimSpace(string(output)), err))
+		log.LogError(fmt.Sprintf("Command failed: %s\nOutput: %s\nError: %v", cmd, strings.TrimSpace(string(output)), err))
 		return string(output), err
 	}
 	return string(output), err
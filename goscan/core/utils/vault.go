@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------------------
+// VAULT
+// ---------------------------------------------------------------------------------------
+// Secrets stored in the DB (e.g., credentials) are encrypted with a key derived from the
+// workspace passphrase (AES-256-GCM, PBKDF2-SHA256). The vault file keeps the salt and
+// a check value, so that a wrong passphrase is rejected instead of producing garbage
+var Const_VAULT_FILE = "vault.json"
+
+const (
+	vaultIterations = 200000
+	vaultCheck      = "goscan-vault"
+)
+
+var ErrVaultLocked = errors.New("vault locked: set the workspace passphrase first (set passphrase <PASSPHRASE>)")
+
+type vaultFile struct {
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Check      string `json:"check"`
+}
+
+var vault = struct {
+	sync.Mutex
+	key []byte
+}{}
+
+// Lock the vault of the current workspace, and unlock it from GOSCAN_PASSPHRASE if set
+func InitVault() {
+	vault.Lock()
+	vault.key = nil
+	vault.Unlock()
+	if p := os.Getenv("GOSCAN_PASSPHRASE"); p != "" {
+		if err := UnlockVault(p); err != nil {
+			Config.Log.LogError(fmt.Sprintf("Cannot unlock the vault: %s", err))
+		}
+	}
+}
+
+func vaultPath() string {
+	return filepath.Join(Config.Outfolder, Const_VAULT_FILE)
+}
+
+// PBKDF2 with HMAC-SHA256 (RFC 8018), for a single 32-byte block
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	prf.Write(salt)
+	binary.Write(prf, binary.BigEndian, uint32(1))
+	u := prf.Sum(nil)
+	key := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+// Unlock the vault of the workspace with its passphrase (the vault is created on first use)
+func UnlockVault(passphrase string) error {
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+	vf := vaultFile{}
+	data, err := ioutil.ReadFile(vaultPath())
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &vf); err != nil {
+			return fmt.Errorf("invalid vault file %s: %s", vaultPath(), err)
+		}
+		salt, err := base64.StdEncoding.DecodeString(vf.Salt)
+		if err != nil {
+			return fmt.Errorf("invalid vault file %s: %s", vaultPath(), err)
+		}
+		key := deriveKey(passphrase, salt, vf.Iterations)
+		if check, err := decryptWith(key, vf.Check); err != nil || check != vaultCheck {
+			return errors.New("wrong passphrase")
+		}
+		vault.Lock()
+		vault.key = key
+		vault.Unlock()
+		return nil
+	case os.IsNotExist(err):
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		key := deriveKey(passphrase, salt, vaultIterations)
+		check, err := encryptWith(key, vaultCheck)
+		if err != nil {
+			return err
+		}
+		vf = vaultFile{Salt: base64.StdEncoding.EncodeToString(salt), Iterations: vaultIterations, Check: check}
+		data, _ := json.MarshalIndent(vf, "", "  ")
+		if err := ioutil.WriteFile(vaultPath(), data, 0600); err != nil {
+			return err
+		}
+		vault.Lock()
+		vault.key = key
+		vault.Unlock()
+		Config.Log.LogInfo(fmt.Sprintf("Vault created: %s", vaultPath()))
+		return nil
+	default:
+		return err
+	}
+}
+
+func VaultUnlocked() bool {
+	vault.Lock()
+	defer vault.Unlock()
+	return vault.key != nil
+}
+
+// Encrypt a secret with the key of the workspace (base64 of nonce + ciphertext)
+func Encrypt(plaintext string) (string, error) {
+	vault.Lock()
+	key := vault.key
+	vault.Unlock()
+	if key == nil {
+		return "", ErrVaultLocked
+	}
+	return encryptWith(key, plaintext)
+}
+
+// Decrypt a secret encrypted with Encrypt
+func Decrypt(ciphertext string) (string, error) {
+	vault.Lock()
+	key := vault.key
+	vault.Unlock()
+	if key == nil {
+		return "", ErrVaultLocked
+	}
+	return decryptWith(key, ciphertext)
+}
+
+func encryptWith(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func decryptWith(key []byte, ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Hide the secrets (passphrase, SNMPv3 passwords) from the command line before it is
+// recorded (audit log)
+func RedactSecrets(cmd string) string {
+	tokens := strings.Fields(cmd)
+	if len(tokens) > 2 && tokens[0] == "set" && tokens[1] == "passphrase" {
+		return "set passphrase ********"
+	}
+	if len(tokens) > 4 && tokens[0] == "set" && tokens[1] == "snmp_v3" {
+		// set snmp_v3 <USER> <AUTH_PROTOCOL> <AUTH_PASS> [<PRIV_PROTOCOL> <PRIV_PASS>]
+		for _, i := range []int{4, 6} {
+			if i < len(tokens) {
+				tokens[i] = "********"
+			}
+		}
+		return strings.Join(tokens, " ")
+	}
+	return cmd
+}
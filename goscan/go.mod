@@ -5,9 +5,12 @@ go 1.21
 require (
 	github.com/c-bata/go-prompt v0.2.6
 	github.com/fatih/color v1.18.0
+	github.com/gosnmp/gosnmp v1.38.0
 	github.com/jinzhu/gorm v1.9.16
 	github.com/lair-framework/go-nmap v0.0.0-20191202052157-3507e0b03523
+	github.com/mattn/go-isatty v0.0.20
 	github.com/olekukonko/tablewriter v0.0.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -15,7 +18,6 @@ require (
 	github.com/clipperhouse/uax29/v2 v2.3.0 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.19 // indirect
 	github.com/mattn/go-sqlite3 v1.14.0 // indirect
 	github.com/mattn/go-tty v0.0.3 // indirect
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"flag"
+
 	"github.com/marco-lancini/goscan/core/cli"
+	"github.com/marco-lancini/goscan/core/scan"
 	"github.com/marco-lancini/goscan/core/utils"
 )
 
@@ -13,12 +16,24 @@ var (
 // ---------------------------------------------------------------------------------------
 // INIT
 // ---------------------------------------------------------------------------------------
+func parseFlags() {
+	flag.StringVar(&utils.Options.LogLevel, "log-level", "", "Log level: debug, info, notify, warning, error (env: GOSCAN_LOG_LEVEL)")
+	flag.StringVar(&utils.Options.LogFormat, "log-format", "", "Format of the log file: text, json (env: GOSCAN_LOG_FORMAT)")
+	flag.BoolVar(&utils.Options.NoAnimation, "no-animation", false, "Disable animations and artificial delays")
+	flag.BoolVar(&utils.Options.NoColor, "no-color", false, "Disable coloured output (env: NO_COLOR)")
+	flag.Parse()
+}
+
 func initCore() {
+	// Parse command line options
+	parseFlags()
 	// Check sudo
 	utils.CheckSudo()
 	// Initialize global config (db, logger, etc.)
 	// From now on it will be accessible as utils.Config
 	utils.InitConfig()
+	// Pause/cancel jobs according to the scanning windows of the workspace
+	go scan.EnforceWindows()
 }
 
 // ---------------------------------------------------------------------------------------